@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"assign-reviewers-for-pull-requests/internal/config"
+	"assign-reviewers-for-pull-requests/internal/jwtauth"
+)
+
+// jwksLoadTimeout ограничивает загрузку JWKS по URL.
+const jwksLoadTimeout = 10 * time.Second
+
+// initJWTVerifier загружает JWKS при старте: без ключей сервис не сможет
+// принять ни одного токена.
+func initJWTVerifier(cfg config.JWTConfig) (*jwtauth.Verifier, error) {
+	source := jwtauth.NewFileKeySource(cfg.JWKSFile)
+	if cfg.JWKSURL != "" {
+		source = jwtauth.NewURLKeySource(cfg.JWKSURL, jwksLoadTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jwksLoadTimeout)
+	defer cancel()
+
+	verifier, err := jwtauth.NewVerifier(ctx, source, jwtauth.Config{
+		Issuer:    cfg.Issuer,
+		Audience:  cfg.Audience,
+		UserClaim: cfg.UserClaim,
+		Leeway:    cfg.Leeway,
+		Refresh:   cfg.JWKSRefresh,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS: %w", err)
+	}
+	return verifier, nil
+}
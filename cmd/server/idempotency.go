@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	"assign-reviewers-for-pull-requests/internal/service"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+)
+
+// idempotencyReplayHeaders — заголовки ответа, которые сохраняются и
+// отдаются при повторе вместе с телом.
+var idempotencyReplayHeaders = []string{"ETag"}
+
+// idempotencyMiddleware повторяет ответ на POST-запрос с уже виденным
+// Idempotency-Key вместо повторного выполнения. Ключ действует в пределах
+// маршрута и своего владельца (см. idempotencyOwner); тот же ключ с другим
+// телом запроса отклоняется с 422.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить.
+func idempotencyMiddleware(idempotency service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		route := c.FullPath()
+		if c.Request.Method != http.MethodPost || key == "" || route == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, errors.ErrBadRequest("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, errors.ErrBadRequest("failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		owner := idempotencyOwner(requestctx.Principal(ctx))
+		hash := sha256.Sum256(body)
+
+		record, err := idempotency.Begin(ctx, owner, key, route, hex.EncodeToString(hash[:]))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if record != nil {
+			c.Header(idempotencyReplayedHeader, "true")
+			for name, value := range record.ResponseHeaders {
+				c.Header(name, value)
+			}
+			c.Data(*record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// recoveryMiddleware стоит раньше и ответит 500 уже после нас, поэтому
+		// при панике ключ освобождается здесь, иначе он так и останется занятым.
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				_ = idempotency.Release(context.WithoutCancel(ctx), owner, key, route)
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		// Результат сохраняется, даже если клиент уже отключился: именно
+		// тогда он и придёт с повтором.
+		ctx = context.WithoutCancel(ctx)
+		if status := writer.Status(); status >= http.StatusInternalServerError {
+			_ = idempotency.Release(ctx, owner, key, route)
+		} else {
+			var headers model.Headers
+			for _, name := range idempotencyReplayHeaders {
+				if value := writer.Header().Get(name); value != "" {
+					if headers == nil {
+						headers = model.Headers{}
+					}
+					headers[name] = value
+				}
+			}
+			_ = idempotency.Complete(ctx, owner, key, route, status, headers, writer.body.Bytes())
+		}
+	}
+}
+
+// idempotencyOwner возвращает владельца ключей идемпотентности: пользователя,
+// а для токена без пользователя — сам токен. Так ключи разных пользователей
+// не пересекаются, а обновление JWT не меняет владельца.
+func idempotencyOwner(principal *model.Principal) string {
+	switch {
+	case principal == nil:
+		return ""
+	case principal.UserID != "":
+		return "user:" + principal.UserID
+	case principal.TokenID != "":
+		return "token:" + principal.TokenID
+	default:
+		return "token:" + principal.Name
+	}
+}
+
+// capturingWriter копирует тело ответа, чтобы сохранить его для повторов.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,9 +18,14 @@ import (
 	"go.uber.org/zap"
 
 	"assign-reviewers-for-pull-requests/internal/config"
+	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/handler"
+	"assign-reviewers-for-pull-requests/internal/metrics"
+	"assign-reviewers-for-pull-requests/internal/notify"
 	"assign-reviewers-for-pull-requests/internal/service"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	"assign-reviewers-for-pull-requests/internal/tracing"
 )
 
 func main() {
@@ -48,10 +56,47 @@ func main() {
 
 	logger.Info("Database connection established")
 
+	// Трассировка
+	tracer, err := initTracer(cfg.Tracing, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	if tracer != nil {
+		tracing.SetTracer(tracer)
+		logger.Info("Tracing enabled", zap.String("exporter", cfg.Tracing.Exporter))
+	}
+
 	// Инициализация слоев приложения
-	repos := repository.NewRepositories(db)
-	services := service.NewServices(repos, logger)
+	repos := repository.NewRepositories(db, cfg.Database.QueryTimeout)
+	authOptions := service.AuthOptions{
+		Enabled:        cfg.Auth.Mode != config.AuthModeNone,
+		BootstrapToken: cfg.Auth.BootstrapToken,
+	}
+	if cfg.Auth.Mode == config.AuthModeJWT {
+		authOptions.JWT, err = initJWTVerifier(cfg.Auth.JWT)
+		if err != nil {
+			logger.Fatal("Failed to initialize JWT verifier", zap.Error(err))
+		}
+	}
+	forgeOptions := service.ForgeOptions{
+		GitHubSecret: cfg.Webhooks.GitHubSecret,
+		GitLabToken:  cfg.Webhooks.GitLabToken,
+	}
+	webhookOptions := service.WebhookOptions{
+		Timeout:     cfg.Webhooks.DeliveryTimeout,
+		BatchSize:   cfg.Webhooks.DeliveryBatchSize,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		RetryBase:   cfg.Webhooks.RetryBase,
+		RetryMax:    cfg.Webhooks.RetryMax,
+	}
+	services := service.NewServices(repos, logger, authOptions, forgeOptions, webhookOptions)
 	handlers := handler.NewHandler(services, logger)
+	idempotency := service.NewIdempotencyService(repos, logger, cfg.Idempotency.TTL)
+	limiter := initRateLimiter(cfg.RateLimit, repos)
+
+	if cfg.Auth.Mode == config.AuthModeNone {
+		logger.Warn("Authentication is disabled, all endpoints are open")
+	}
 
 	// Настройка Gin
 	if cfg.Log.Level == "production" {
@@ -59,19 +104,40 @@ func main() {
 	}
 
 	router := gin.New()
-	router.Use(gin.Recovery())
+	router.Use(metricsMiddleware())
+	router.Use(tracingMiddleware())
+	router.Use(requestContextMiddleware(logger))
 	router.Use(loggerMiddleware(logger))
+	router.Use(recoveryMiddleware())
+	router.Use(handlers.Authenticate())
+	if limiter != nil {
+		router.Use(rateLimitMiddleware(limiter, cfg.RateLimit, logger))
+	}
+	router.Use(idempotencyMiddleware(idempotency))
 
 	// Регистрация роутов
 	handlers.InitRoutes(router)
 
-	// Создание HTTP сервера
+	// Метрики Prometheus
+	registerMetrics(db, services.Stats)
+	router.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+
+	// Контекст всех запросов: отменяется, если они не завершились за время
+	// остановки сервера, и прерывает их запросы к БД
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	// Создание HTTP сервера; выгрузки CSV/NDJSON продлевают срок записи
+	// сами (handler/export.go)
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      router,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	// Graceful shutdown
@@ -83,6 +149,32 @@ func main() {
 
 	logger.Info("Server started successfully", zap.String("port", cfg.Server.Port))
 
+	// Планировщик просроченных ревью
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+
+	go runIdempotencyCleanup(schedulerCtx, idempotency, logger)
+	go runWebhookDelivery(schedulerCtx, cfg.Webhooks.DeliveryInterval, services.Webhook, logger)
+
+	if limiter != nil {
+		go runRateLimitCleanup(schedulerCtx, limiter, cfg.RateLimit.MaxPeriod(), logger)
+		logger.Info("Rate limiting enabled", zap.String("backend", cfg.RateLimit.Backend))
+	}
+
+	if cfg.Stale.Enabled {
+		var notifier notify.Notifier
+		if cfg.Stale.NotifyURL != "" {
+			notifier = notify.NewWebhookNotifier(cfg.Stale.NotifyURL, cfg.Stale.NotifyTimeout)
+		} else {
+			notifier = notify.NewLogNotifier(logger)
+		}
+
+		stale := service.NewStaleReviewService(repos, logger, notifier, cfg.Stale.DefaultSLAHours, cfg.Stale.BatchSize)
+		go runStaleReviewScheduler(schedulerCtx, cfg.Stale.Interval, stale, logger)
+
+		logger.Info("Stale review scheduler started", zap.Duration("interval", cfg.Stale.Interval))
+	}
+
 	// Ожидание сигнала завершения
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -90,11 +182,21 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stopScheduler()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal("Server forced to shutdown", zap.Error(err))
+		logger.Warn("Shutdown timed out, cancelling in-flight requests", zap.Error(err))
+		cancelRequests()
+		srv.Close()
+	}
+
+	if tracer != nil {
+		if err := tracer.Shutdown(ctx); err != nil {
+			logger.Warn("Failed to flush traces", zap.Error(err))
+		}
 	}
 
 	logger.Info("Server exited")
@@ -155,7 +257,7 @@ func loggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 
 		duration := time.Since(start)
 
-		logger.Info("HTTP Request",
+		requestctx.Logger(c.Request.Context(), logger).Info("HTTP Request",
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
@@ -164,4 +266,73 @@ func loggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("ip", c.ClientIP()),
 		)
 	}
+}
+
+// requestContextMiddleware переносит в контекст запроса названного клиентом
+// инициатора изменений, идентификатор запроса и логгер с этим идентификатором. X-Request-ID берётся
+// из запроса, а если его нет или он некорректен — генерируется, и в любом
+// случае возвращается в ответе.
+func requestContextMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header("X-Request-ID", requestID)
+
+		ctx := c.Request.Context()
+		// Длинное значение не поместилось бы в claimed_actor и сорвало бы запись аудита
+		if actor := c.GetHeader("X-Actor-ID"); actor != "" && len(actor) <= 255 {
+			ctx = requestctx.WithClaimedActor(ctx, actor)
+		}
+		ctx = requestctx.WithRequestID(ctx, requestID)
+		ctx = requestctx.WithLogger(ctx, logger.With(zap.String("request_id", requestID)))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// validRequestID пропускает только короткие идентификаторы из безопасных
+// символов: значение попадает в логи и заголовки ответа.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// recoveryMiddleware отвечает на панику обработчика ошибкой в обычном
+// формате, с идентификатором запроса.
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		abortWithError(c, errors.NewAppError(errors.ErrCodeInternal, "internal error", http.StatusInternalServerError))
+	})
+}
+
+// abortWithError прерывает запрос в middleware ошибкой в том же формате,
+// что и у обработчиков.
+func abortWithError(c *gin.Context, err error) {
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.ErrInternal(err)
+	}
+
+	body := *appErr
+	body.RequestID = requestctx.RequestID(c.Request.Context())
+	c.AbortWithStatusJSON(appErr.HTTPStatus, errors.ErrorResponse{Error: body})
 }
\ No newline at end of file
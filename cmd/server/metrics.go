@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+
+	"assign-reviewers-for-pull-requests/internal/metrics"
+	"assign-reviewers-for-pull-requests/internal/service"
+)
+
+// metricsMiddleware пишет длительность запросов в гистограмму по маршруту и статусу.
+// Маршрут берётся шаблоном из роутера, чтобы не плодить серии на каждый URL.
+func metricsMiddleware() gin.HandlerFunc {
+	duration := metrics.Default.NewHistogramVec(
+		"http_request_duration_seconds", "HTTP request latency by route and status.",
+		metrics.DefaultBuckets, "method", "route", "status")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		duration.Observe(time.Since(start).Seconds(), c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
+
+// registerMetrics добавляет метрики, которые считываются в момент сбора:
+// состояние пула соединений и открытые ревью по командам.
+func registerMetrics(db *sqlx.DB, stats service.StatsService) {
+	gauge := func(name, help string, value func() float64) {
+		metrics.Default.NewGaugeFunc(name, help, nil, func(ctx context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: value()}}, nil
+		})
+	}
+	counter := func(name, help string, value func() float64) {
+		metrics.Default.NewCounterFunc(name, help, nil, func(ctx context.Context) ([]metrics.Sample, error) {
+			return []metrics.Sample{{Value: value()}}, nil
+		})
+	}
+
+	gauge("db_max_open_connections", "Maximum number of open connections to the database.",
+		func() float64 { return float64(db.Stats().MaxOpenConnections) })
+	gauge("db_open_connections", "Established connections, both in use and idle.",
+		func() float64 { return float64(db.Stats().OpenConnections) })
+	gauge("db_in_use_connections", "Connections currently in use.",
+		func() float64 { return float64(db.Stats().InUse) })
+	gauge("db_idle_connections", "Idle connections.",
+		func() float64 { return float64(db.Stats().Idle) })
+	counter("db_wait_count_total", "Connections waited for.",
+		func() float64 { return float64(db.Stats().WaitCount) })
+	counter("db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+		func() float64 { return db.Stats().WaitDuration.Seconds() })
+	counter("db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.",
+		func() float64 { return float64(db.Stats().MaxIdleClosed) })
+	counter("db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.",
+		func() float64 { return float64(db.Stats().MaxLifetimeClosed) })
+
+	metrics.Default.NewGaugeFunc("open_reviews", "Open review assignments per user, by pull request team.",
+		[]string{"team", "user_id"}, func(ctx context.Context) ([]metrics.Sample, error) {
+			load, err := stats.GetOpenReviewLoad(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			samples := make([]metrics.Sample, len(load))
+			for i, l := range load {
+				samples[i] = metrics.Sample{
+					LabelValues: []string{l.TeamName, l.UserID},
+					Value:       float64(l.OpenReviews),
+				}
+			}
+			return samples, nil
+		})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"assign-reviewers-for-pull-requests/internal/config"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/ratelimit"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+)
+
+// rateLimitExempt — служебные маршруты, которые не ограничиваются, чтобы
+// не мешать проверкам живости и сбору метрик.
+var rateLimitExempt = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// rateLimitCleanupInterval — как часто удалять неиспользуемые bucket'ы.
+const rateLimitCleanupInterval = 10 * time.Minute
+
+// initRateLimiter возвращает nil, если ограничение выключено.
+func initRateLimiter(cfg config.RateLimitConfig, repos *repository.Repositories) ratelimit.Limiter {
+	switch cfg.Backend {
+	case config.RateLimitBackendMemory:
+		return ratelimit.NewMemoryLimiter()
+	case config.RateLimitBackendPostgres:
+		return ratelimit.NewStoreLimiter(repos.RateLimit)
+	}
+	return nil
+}
+
+// rateLimitMiddleware ограничивает запросы клиента: токена, а без него —
+// IP-адреса. Маршрут с собственным лимитом получает отдельный bucket,
+// остальные делят общий. Если хранилище лимитов недоступно, запрос
+// пропускается.
+func rateLimitMiddleware(limiter ratelimit.Limiter, cfg config.RateLimitConfig, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if rateLimitExempt[route] {
+			c.Next()
+			return
+		}
+
+		bucket, limit := "*", cfg.Default
+		if routeLimit, ok := cfg.Routes[c.Request.Method+" "+route]; ok && route != "" {
+			bucket, limit = c.Request.Method+" "+route, routeLimit
+		} else if routeLimit, ok := cfg.Routes[route]; ok && route != "" {
+			bucket, limit = route, routeLimit
+		}
+		if limit.Requests == 0 {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		result, err := limiter.Allow(ctx, rateLimitClient(c)+"|"+bucket, ratelimit.Limit(limit))
+		if err != nil {
+			requestctx.Logger(ctx, logger).Warn("Rate limit check failed", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			abortWithError(c, errors.ErrRateLimited())
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitClient(c *gin.Context) string {
+	principal := requestctx.Principal(c.Request.Context())
+	switch {
+	case principal == nil:
+		return "ip:" + c.ClientIP()
+	case principal.TokenID != "":
+		return "token:" + principal.TokenID
+	default:
+		return "user:" + principal.UserID
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"assign-reviewers-for-pull-requests/internal/ratelimit"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	"assign-reviewers-for-pull-requests/internal/service"
+)
+
+// staleReviewActor — инициатор изменений, сделанных планировщиком, в журнале аудита.
+const staleReviewActor = "system:stale-review"
+
+// runStaleReviewScheduler периодически обходит просроченные ревью, пока не отменён ctx.
+// Обход выполняет только экземпляр, захвативший блокировку в БД.
+func runStaleReviewScheduler(ctx context.Context, interval time.Duration, stale service.StaleReviewService, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx = requestctx.WithActor(ctx, staleReviewActor)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := stale.SweepStaleReviews(ctx)
+			if err != nil {
+				logger.Error("Stale review sweep failed", zap.Error(err))
+				continue
+			}
+
+			if !result.Acquired {
+				logger.Debug("Stale review sweep skipped: lock held by another instance")
+				continue
+			}
+
+			logger.Info("Stale review sweep finished",
+				zap.Int("nudged", result.Nudged),
+				zap.Int("added", result.Added),
+				zap.Int("reassigned", result.Reassigned),
+				zap.Int("failed", result.Failed),
+			)
+		}
+	}
+}
+
+// idempotencyCleanupInterval — как часто удалять просроченные ключи идемпотентности.
+const idempotencyCleanupInterval = time.Hour
+
+func runIdempotencyCleanup(ctx context.Context, idempotency service.IdempotencyService, logger *zap.Logger) {
+	ticker := time.NewTicker(idempotencyCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := idempotency.DeleteExpired(ctx)
+			if err != nil {
+				logger.Error("Idempotency keys cleanup failed", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				logger.Debug("Expired idempotency keys deleted", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+func runRateLimitCleanup(ctx context.Context, limiter ratelimit.Limiter, idle time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := limiter.Cleanup(ctx, idle)
+			if err != nil {
+				logger.Error("Rate limit buckets cleanup failed", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				logger.Debug("Idle rate limit buckets deleted", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// runWebhookDelivery периодически отправляет подписчикам события из очереди.
+// Экземпляры сервиса забирают разные доставки, поэтому воркер работает на каждом.
+func runWebhookDelivery(ctx context.Context, interval time.Duration, webhooks service.WebhookService, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := webhooks.DeliverPending(ctx)
+			if err != nil {
+				logger.Error("Webhook delivery failed", zap.Error(err))
+				continue
+			}
+			if result.Delivered+result.Retried+result.Dead > 0 {
+				logger.Debug("Webhook deliveries processed",
+					zap.Int("delivered", result.Delivered),
+					zap.Int("retried", result.Retried),
+					zap.Int("dead", result.Dead),
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"assign-reviewers-for-pull-requests/internal/config"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// initTracer создаёт трассировщик по конфигурации. При выключенной
+// трассировке возвращает nil, и спаны не создаются.
+func initTracer(cfg config.TracingConfig, logger *zap.Logger) (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+
+	switch cfg.Exporter {
+	case config.TracingExporterNone:
+		return nil, nil
+	case config.TracingExporterStdout:
+		exporter = tracing.NewWriterExporter(cfg.ServiceName, os.Stdout)
+	case config.TracingExporterFile:
+		file, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter = tracing.NewWriterExporter(cfg.ServiceName, file)
+	case config.TracingExporterOTLP:
+		exporter = tracing.NewOTLPExporter(cfg.ServiceName, cfg.OTLPEndpoint, cfg.ExportTimeout)
+	}
+
+	return tracing.NewTracer(exporter, logger), nil
+}
+
+// tracingMiddleware открывает серверный спан на каждый запрос. Родитель
+// берётся из заголовка traceparent, ID трассы возвращается в X-Trace-ID.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if parent, ok := tracing.ParseTraceparent(c.GetHeader("traceparent")); ok {
+			ctx = tracing.ContextWithSpanContext(ctx, parent)
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, span := tracing.StartWithKind(ctx, tracing.KindServer, c.Request.Method+" "+route,
+			tracing.String("http.method", c.Request.Method),
+			tracing.String("http.route", route),
+		)
+		defer span.End()
+
+		if span != nil {
+			c.Header("X-Trace-ID", span.SpanContext().TraceID.String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(tracing.Int("http.status_code", status))
+		if status >= 500 {
+			span.RecordError(fmt.Errorf("HTTP %d", status))
+		}
+	}
+}
@@ -4,31 +4,129 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Log      LogConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Log         LogConfig
+	Stale       StaleReviewConfig
+	Tracing     TracingConfig
+	Idempotency IdempotencyConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	Webhooks    WebhooksConfig
 }
 
 type ServerConfig struct {
-	Port string
+	Port            string
+	ShutdownTimeout time.Duration
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host         string
+	Port         int
+	User         string
+	Password     string
+	DBName       string
+	SSLMode      string
+	QueryTimeout time.Duration // 0 — без ограничения
 }
 
 type LogConfig struct {
 	Level string
 }
 
+type StaleReviewConfig struct {
+	Enabled         bool
+	Interval        time.Duration
+	DefaultSLAHours int
+	BatchSize       int
+	NotifyURL       string
+	NotifyTimeout   time.Duration
+}
+
+// Экспортёры трассировки.
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+	TracingExporterOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	Exporter      string
+	ServiceName   string
+	FilePath      string
+	OTLPEndpoint  string
+	ExportTimeout time.Duration
+}
+
+type IdempotencyConfig struct {
+	TTL time.Duration
+}
+
+// Режимы аутентификации API.
+const (
+	AuthModeNone  = "none"
+	AuthModeToken = "token"
+	AuthModeJWT   = "jwt"
+)
+
+type AuthConfig struct {
+	Mode           string
+	BootstrapToken string
+	JWT            JWTConfig
+}
+
+// JWTConfig — проверка JWT сторонних издателей; ключи берутся из JWKS в
+// файле или по URL.
+type JWTConfig struct {
+	JWKSFile    string
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	Issuer      string
+	Audience    string
+	UserClaim   string
+	Leeway      time.Duration
+}
+
+// Хранилища лимитов запросов.
+const (
+	RateLimitBackendNone     = "none"
+	RateLimitBackendMemory   = "memory"
+	RateLimitBackendPostgres = "postgres"
+)
+
+// RateLimit — не больше Requests запросов за Period; Requests = 0 — без ограничения.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+type RateLimitConfig struct {
+	Backend string
+	Default RateLimit
+	// Routes — лимиты отдельных маршрутов по ключу "METHOD /path" или "/path".
+	Routes map[string]RateLimit
+}
+
+// WebhooksConfig — секреты входящих webhook'ов (пустой секрет выключает
+// приём событий) и доставка исходящих событий подписчикам.
+type WebhooksConfig struct {
+	GitHubSecret string
+	GitLabToken  string
+
+	DeliveryInterval  time.Duration
+	DeliveryTimeout   time.Duration
+	DeliveryBatchSize int
+	MaxAttempts       int
+	RetryBase         time.Duration
+	RetryMax          time.Duration
+}
+
 func Load() (*Config, error) {
 	dbHost := os.Getenv("DB_HOST")
 	if dbHost == "" {
@@ -56,21 +154,199 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid DB_PORT: %w", err)
 	}
 
+	dbQueryTimeout, err := time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", "5s"))
+	if err != nil || dbQueryTimeout < 0 {
+		return nil, fmt.Errorf("invalid DB_QUERY_TIMEOUT: %q", os.Getenv("DB_QUERY_TIMEOUT"))
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "5s"))
+	if err != nil || shutdownTimeout <= 0 {
+		return nil, fmt.Errorf("invalid SHUTDOWN_TIMEOUT: %q", os.Getenv("SHUTDOWN_TIMEOUT"))
+	}
+
+	staleEnabled, err := strconv.ParseBool(getEnv("STALE_REVIEW_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STALE_REVIEW_ENABLED: %w", err)
+	}
+
+	staleInterval, err := time.ParseDuration(getEnv("STALE_REVIEW_INTERVAL", "10m"))
+	if err != nil || staleInterval <= 0 {
+		return nil, fmt.Errorf("invalid STALE_REVIEW_INTERVAL: %q", os.Getenv("STALE_REVIEW_INTERVAL"))
+	}
+
+	staleDefaultSLA, err := strconv.Atoi(getEnv("STALE_REVIEW_DEFAULT_SLA_HOURS", "0"))
+	if err != nil || staleDefaultSLA < 0 {
+		return nil, fmt.Errorf("invalid STALE_REVIEW_DEFAULT_SLA_HOURS: %q", os.Getenv("STALE_REVIEW_DEFAULT_SLA_HOURS"))
+	}
+
+	staleBatchSize, err := strconv.Atoi(getEnv("STALE_REVIEW_BATCH_SIZE", "100"))
+	if err != nil || staleBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid STALE_REVIEW_BATCH_SIZE: %q", os.Getenv("STALE_REVIEW_BATCH_SIZE"))
+	}
+
+	staleNotifyTimeout, err := time.ParseDuration(getEnv("STALE_REVIEW_NOTIFY_TIMEOUT", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STALE_REVIEW_NOTIFY_TIMEOUT: %w", err)
+	}
+
+	webhookInterval, err := time.ParseDuration(getEnv("WEBHOOK_DELIVERY_INTERVAL", "5s"))
+	if err != nil || webhookInterval <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DELIVERY_INTERVAL: %q", os.Getenv("WEBHOOK_DELIVERY_INTERVAL"))
+	}
+
+	webhookTimeout, err := time.ParseDuration(getEnv("WEBHOOK_DELIVERY_TIMEOUT", "10s"))
+	if err != nil || webhookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DELIVERY_TIMEOUT: %q", os.Getenv("WEBHOOK_DELIVERY_TIMEOUT"))
+	}
+
+	webhookBatchSize, err := strconv.Atoi(getEnv("WEBHOOK_DELIVERY_BATCH_SIZE", "50"))
+	if err != nil || webhookBatchSize <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_DELIVERY_BATCH_SIZE: %q", os.Getenv("WEBHOOK_DELIVERY_BATCH_SIZE"))
+	}
+
+	webhookMaxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "10"))
+	if err != nil || webhookMaxAttempts <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	}
+
+	webhookRetryBase, err := time.ParseDuration(getEnv("WEBHOOK_RETRY_BASE", "30s"))
+	if err != nil || webhookRetryBase <= 0 {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_BASE: %q", os.Getenv("WEBHOOK_RETRY_BASE"))
+	}
+
+	webhookRetryMax, err := time.ParseDuration(getEnv("WEBHOOK_RETRY_MAX", "6h"))
+	if err != nil || webhookRetryMax < webhookRetryBase {
+		return nil, fmt.Errorf("invalid WEBHOOK_RETRY_MAX: %q", os.Getenv("WEBHOOK_RETRY_MAX"))
+	}
+
+	tracingExporter := getEnv("TRACING_EXPORTER", TracingExporterNone)
+	switch tracingExporter {
+	case TracingExporterNone, TracingExporterStdout, TracingExporterFile, TracingExporterOTLP:
+	default:
+		return nil, fmt.Errorf("invalid TRACING_EXPORTER: %q", tracingExporter)
+	}
+
+	tracingEndpoint := os.Getenv("TRACING_OTLP_ENDPOINT")
+	if tracingExporter == TracingExporterOTLP && tracingEndpoint == "" {
+		return nil, fmt.Errorf("TRACING_OTLP_ENDPOINT is required for otlp exporter")
+	}
+
+	tracingTimeout, err := time.ParseDuration(getEnv("TRACING_EXPORT_TIMEOUT", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRACING_EXPORT_TIMEOUT: %w", err)
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || idempotencyTTL <= 0 {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL: %q", os.Getenv("IDEMPOTENCY_TTL"))
+	}
+
+	authMode := getEnv("AUTH_MODE", AuthModeNone)
+	switch authMode {
+	case AuthModeNone, AuthModeToken, AuthModeJWT:
+	default:
+		return nil, fmt.Errorf("invalid AUTH_MODE: %q", authMode)
+	}
+
+	jwtConfig := JWTConfig{
+		JWKSFile:  os.Getenv("JWT_JWKS_FILE"),
+		JWKSURL:   os.Getenv("JWT_JWKS_URL"),
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audience:  os.Getenv("JWT_AUDIENCE"),
+		UserClaim: getEnv("JWT_USER_CLAIM", "sub"),
+	}
+	if authMode == AuthModeJWT {
+		if (jwtConfig.JWKSFile == "") == (jwtConfig.JWKSURL == "") {
+			return nil, fmt.Errorf("exactly one of JWT_JWKS_FILE and JWT_JWKS_URL is required for jwt auth")
+		}
+		if jwtConfig.Issuer == "" || jwtConfig.Audience == "" {
+			return nil, fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE are required for jwt auth")
+		}
+	}
+
+	jwtConfig.JWKSRefresh, err = time.ParseDuration(getEnv("JWT_JWKS_REFRESH", "1h"))
+	if err != nil || jwtConfig.JWKSRefresh <= 0 {
+		return nil, fmt.Errorf("invalid JWT_JWKS_REFRESH: %q", os.Getenv("JWT_JWKS_REFRESH"))
+	}
+
+	jwtConfig.Leeway, err = time.ParseDuration(getEnv("JWT_LEEWAY", "30s"))
+	if err != nil || jwtConfig.Leeway < 0 {
+		return nil, fmt.Errorf("invalid JWT_LEEWAY: %q", os.Getenv("JWT_LEEWAY"))
+	}
+
+	rateLimitBackend := getEnv("RATE_LIMIT_BACKEND", RateLimitBackendNone)
+	switch rateLimitBackend {
+	case RateLimitBackendNone, RateLimitBackendMemory, RateLimitBackendPostgres:
+	default:
+		return nil, fmt.Errorf("invalid RATE_LIMIT_BACKEND: %q", rateLimitBackend)
+	}
+
+	rateLimitDefault, err := parseRateLimit(getEnv("RATE_LIMIT_DEFAULT", "600/1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_DEFAULT: %w", err)
+	}
+
+	rateLimitRoutes, err := parseRateLimitRoutes(os.Getenv("RATE_LIMIT_ROUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+	}
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:            getEnv("SERVER_PORT", "8080"),
+			ShutdownTimeout: shutdownTimeout,
 		},
 		Database: DatabaseConfig{
-			Host:     dbHost,
-			Port:     dbPort,
-			User:     dbUser,
-			Password: dbPassword,
-			DBName:   dbName,
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:         dbHost,
+			Port:         dbPort,
+			User:         dbUser,
+			Password:     dbPassword,
+			DBName:       dbName,
+			SSLMode:      getEnv("DB_SSLMODE", "disable"),
+			QueryTimeout: dbQueryTimeout,
 		},
 		Log: LogConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
+		Stale: StaleReviewConfig{
+			Enabled:         staleEnabled,
+			Interval:        staleInterval,
+			DefaultSLAHours: staleDefaultSLA,
+			BatchSize:       staleBatchSize,
+			NotifyURL:       os.Getenv("STALE_REVIEW_NOTIFY_URL"),
+			NotifyTimeout:   staleNotifyTimeout,
+		},
+		Tracing: TracingConfig{
+			Exporter:      tracingExporter,
+			ServiceName:   getEnv("TRACING_SERVICE_NAME", "pr-reviewer-service"),
+			FilePath:      getEnv("TRACING_FILE", "traces.jsonl"),
+			OTLPEndpoint:  tracingEndpoint,
+			ExportTimeout: tracingTimeout,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: idempotencyTTL,
+		},
+		Auth: AuthConfig{
+			Mode:           authMode,
+			BootstrapToken: os.Getenv("AUTH_BOOTSTRAP_TOKEN"),
+			JWT:            jwtConfig,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: rateLimitBackend,
+			Default: rateLimitDefault,
+			Routes:  rateLimitRoutes,
+		},
+		Webhooks: WebhooksConfig{
+			GitHubSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+			GitLabToken:  os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+
+			DeliveryInterval:  webhookInterval,
+			DeliveryTimeout:   webhookTimeout,
+			DeliveryBatchSize: webhookBatchSize,
+			MaxAttempts:       webhookMaxAttempts,
+			RetryBase:         webhookRetryBase,
+			RetryMax:          webhookRetryMax,
+		},
 	}, nil
 }
 
@@ -81,6 +357,63 @@ func (c *DatabaseConfig) DSN() string {
 	)
 }
 
+// MaxPeriod — за это время простоя любой bucket наполняется полностью, и
+// его можно удалить.
+func (c RateLimitConfig) MaxPeriod() time.Duration {
+	period := c.Default.Period
+	for _, limit := range c.Routes {
+		if limit.Period > period {
+			period = limit.Period
+		}
+	}
+	return period
+}
+
+// parseRateLimit разбирает лимит вида "10/1m".
+func parseRateLimit(value string) (RateLimit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected <requests>/<period>, got %q", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return RateLimit{}, fmt.Errorf("invalid number of requests in %q", value)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid period in %q", value)
+	}
+
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// parseRateLimitRoutes разбирает список вида
+// "POST /pullRequest/create=10/1m,/stats=30/1m".
+func parseRateLimitRoutes(value string) (map[string]RateLimit, error) {
+	routes := make(map[string]RateLimit)
+	if strings.TrimSpace(value) == "" {
+		return routes, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		route, limit, ok := strings.Cut(item, "=")
+		route = strings.Join(strings.Fields(route), " ")
+		if !ok || route == "" {
+			return nil, fmt.Errorf("expected <route>=<requests>/<period>, got %q", item)
+		}
+
+		parsed, err := parseRateLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		routes[route] = parsed
+	}
+
+	return routes, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
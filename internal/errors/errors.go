@@ -1,6 +1,8 @@
 package errors
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"net/http"
 )
@@ -11,16 +13,33 @@ const (
 	ErrCodeTeamExists   ErrorCode = "TEAM_EXISTS"
 	ErrCodePRExists     ErrorCode = "PR_EXISTS"
 	ErrCodePRMerged     ErrorCode = "PR_MERGED"
+	ErrCodePRClosed     ErrorCode = "PR_CLOSED"
 	ErrCodeNotAssigned  ErrorCode = "NOT_ASSIGNED"
 	ErrCodeNoCandidate  ErrorCode = "NO_CANDIDATE"
+	ErrCodeMemberExists ErrorCode = "MEMBER_EXISTS"
+	ErrCodeNotMember    ErrorCode = "NOT_MEMBER"
+	ErrCodeUserDeleted  ErrorCode = "USER_DELETED"
+	ErrCodeTeamArchived ErrorCode = "TEAM_ARCHIVED"
+	ErrCodeTeamHasPRs   ErrorCode = "TEAM_HAS_OPEN_PRS"
 	ErrCodeNotFound     ErrorCode = "NOT_FOUND"
 	ErrCodeInternal     ErrorCode = "INTERNAL_ERROR"
 	ErrCodeBadRequest   ErrorCode = "BAD_REQUEST"
+	ErrCodeTimeout      ErrorCode = "TIMEOUT"
+
+	ErrCodeVersionConflict     ErrorCode = "VERSION_CONFLICT"
+	ErrCodeIdempotencyMismatch ErrorCode = "IDEMPOTENCY_KEY_MISMATCH"
+	ErrCodeIdempotencyInUse    ErrorCode = "IDEMPOTENCY_KEY_IN_USE"
+
+	ErrCodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	ErrCodeInsufficientScope ErrorCode = "INSUFFICIENT_SCOPE"
+	ErrCodeForbidden         ErrorCode = "FORBIDDEN"
+	ErrCodeRateLimited       ErrorCode = "RATE_LIMITED"
 )
 
 type AppError struct {
 	Code       ErrorCode `json:"code"`
 	Message    string    `json:"message"`
+	RequestID  string    `json:"request_id,omitempty"`
 	HTTPStatus int       `json:"-"`
 }
 
@@ -65,6 +84,14 @@ func ErrPRMerged() *AppError {
 	)
 }
 
+func ErrPRClosed() *AppError {
+	return NewAppError(
+		ErrCodePRClosed,
+		"cannot modify closed pull request",
+		http.StatusConflict,
+	)
+}
+
 func ErrNotAssigned() *AppError {
 	return NewAppError(
 		ErrCodeNotAssigned,
@@ -81,6 +108,102 @@ func ErrNoCandidate() *AppError {
 	)
 }
 
+func ErrMemberExists(userID, teamName string) *AppError {
+	return NewAppError(
+		ErrCodeMemberExists,
+		fmt.Sprintf("user '%s' is already a member of team '%s'", userID, teamName),
+		http.StatusConflict,
+	)
+}
+
+func ErrNotMember(userID, teamName string) *AppError {
+	return NewAppError(
+		ErrCodeNotMember,
+		fmt.Sprintf("user '%s' is not a member of team '%s'", userID, teamName),
+		http.StatusConflict,
+	)
+}
+
+func ErrUserDeleted(userID string) *AppError {
+	return NewAppError(
+		ErrCodeUserDeleted,
+		fmt.Sprintf("user '%s' was deleted", userID),
+		http.StatusConflict,
+	)
+}
+
+func ErrTeamArchived(teamName string) *AppError {
+	return NewAppError(
+		ErrCodeTeamArchived,
+		fmt.Sprintf("team '%s' is archived", teamName),
+		http.StatusConflict,
+	)
+}
+
+func ErrTeamHasOpenPRs(teamName string, count int) *AppError {
+	return NewAppError(
+		ErrCodeTeamHasPRs,
+		fmt.Sprintf("team '%s' has %d open pull requests; provide target_team_name to migrate them", teamName, count),
+		http.StatusConflict,
+	)
+}
+
+func ErrVersionConflict() *AppError {
+	return NewAppError(
+		ErrCodeVersionConflict,
+		"pull request was modified by another request; reload it and retry",
+		http.StatusConflict,
+	)
+}
+
+func ErrIdempotencyMismatch() *AppError {
+	return NewAppError(
+		ErrCodeIdempotencyMismatch,
+		"idempotency key was already used with a different request",
+		http.StatusUnprocessableEntity,
+	)
+}
+
+func ErrIdempotencyInUse() *AppError {
+	return NewAppError(
+		ErrCodeIdempotencyInUse,
+		"request with this idempotency key is still in progress",
+		http.StatusConflict,
+	)
+}
+
+func ErrUnauthorized(message string) *AppError {
+	return NewAppError(
+		ErrCodeUnauthorized,
+		message,
+		http.StatusUnauthorized,
+	)
+}
+
+func ErrInsufficientScope(scope string) *AppError {
+	return NewAppError(
+		ErrCodeInsufficientScope,
+		fmt.Sprintf("token lacks required scope '%s'", scope),
+		http.StatusForbidden,
+	)
+}
+
+func ErrForbidden(message string) *AppError {
+	return NewAppError(
+		ErrCodeForbidden,
+		message,
+		http.StatusForbidden,
+	)
+}
+
+func ErrRateLimited() *AppError {
+	return NewAppError(
+		ErrCodeRateLimited,
+		"too many requests; retry after the time in Retry-After",
+		http.StatusTooManyRequests,
+	)
+}
+
 func ErrNotFound(resource string) *AppError {
 	return NewAppError(
 		ErrCodeNotFound,
@@ -89,7 +212,16 @@ func ErrNotFound(resource string) *AppError {
 	)
 }
 
+// ErrInternal оборачивает непредвиденную ошибку; превышение таймаута
+// запроса к БД возвращается отдельным кодом, чтобы клиент мог повторить запрос.
 func ErrInternal(err error) *AppError {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return NewAppError(
+			ErrCodeTimeout,
+			"request timed out",
+			http.StatusServiceUnavailable,
+		)
+	}
 	return NewAppError(
 		ErrCodeInternal,
 		fmt.Sprintf("internal error: %v", err),
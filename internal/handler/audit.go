@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+const defaultAuditListLimit = 50
+
+func (h *Handler) listAuditEvents(c *gin.Context) {
+	var query model.ListAuditQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.log(c).Warn("Invalid query parameters", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultAuditListLimit
+	}
+
+	events, err := h.services.Audit.ListEvents(c.Request.Context(), model.AuditFilter{
+		Actor:        query.Actor,
+		ClaimedActor: query.ClaimedActor,
+		Action:       query.Action,
+		EntityType:   query.EntityType,
+		EntityID:     query.EntityID,
+		From:         query.From,
+		To:           query.To,
+		Limit:        query.Limit,
+		Offset:       query.Offset,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
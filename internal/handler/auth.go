@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+)
+
+// Authenticate проверяет токен из заголовка Authorization и сохраняет его
+// владельца в контексте запроса. Запрос без токена пропускается: его
+// отклонит requireScope, если маршрут требует прав. Инициатором становится
+// пользователь токена, а у токена без пользователя — сам токен; X-Actor-ID
+// на инициатора не влияет.
+func (h *Handler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !h.services.Auth.Enabled() || header == "" {
+			c.Next()
+			return
+		}
+
+		scheme, rawToken, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || rawToken == "" {
+			h.respondError(c, errors.ErrUnauthorized("expected Authorization: Bearer <token>"))
+			c.Abort()
+			return
+		}
+
+		ctx := c.Request.Context()
+		principal, err := h.services.Auth.Authenticate(ctx, strings.TrimSpace(rawToken))
+		if err != nil {
+			h.respondError(c, err)
+			c.Abort()
+			return
+		}
+
+		ctx = requestctx.WithPrincipal(ctx, principal)
+		if principal.UserID != "" {
+			ctx = requestctx.WithActor(ctx, principal.UserID)
+		} else {
+			ctx = requestctx.WithActor(ctx, "token:"+principal.Name)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// requireScope пропускает запрос, только если его токен имеет область scope.
+func (h *Handler) requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.services.Auth.Enabled() {
+			c.Next()
+			return
+		}
+
+		principal := requestctx.Principal(c.Request.Context())
+		if principal == nil {
+			c.Header("WWW-Authenticate", "Bearer")
+			h.respondError(c, errors.ErrUnauthorized("authentication required"))
+			c.Abort()
+			return
+		}
+		if !principal.HasScope(scope) {
+			h.respondError(c, errors.ErrInsufficientScope(scope))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (h *Handler) issueToken(c *gin.Context) {
+	var req model.IssueTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	token, err := h.services.Auth.IssueToken(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token": token,
+	})
+}
+
+func (h *Handler) listTokens(c *gin.Context) {
+	tokens, err := h.services.Auth.ListTokens(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+	})
+}
+
+func (h *Handler) revokeToken(c *gin.Context) {
+	var req model.RevokeTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	token, err := h.services.Auth.RevokeToken(c.Request.Context(), req.TokenID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+	})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+// exportFlushEvery — через сколько строк отдавать накопленное клиенту.
+const exportFlushEvery = 100
+
+// exportWriteTimeout заменяет WriteTimeout сервера для выгрузки: срок записи
+// продлевается на это время с каждой порцией, так что ограничено только
+// время на одну порцию, а не на всю выгрузку.
+const exportWriteTimeout = 30 * time.Second
+
+// exportFormat определяет формат выгрузки по параметру format, а без него —
+// по заголовку Accept. Пустая строка означает обычный JSON-ответ.
+func (h *Handler) exportFormat(c *gin.Context) (string, bool) {
+	switch format := c.Query("format"); format {
+	case "", "json":
+	case exportFormatCSV, exportFormatNDJSON:
+		return format, true
+	default:
+		h.badRequest(c, "invalid format: expected csv or ndjson")
+		return "", false
+	}
+
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return exportFormatCSV, true
+	case strings.Contains(accept, "application/x-ndjson"), strings.Contains(accept, "application/ndjson"):
+		return exportFormatNDJSON, true
+	}
+
+	return "", true
+}
+
+// exporter пишет строки выгрузки прямо в ответ по мере чтения из БД.
+// Заголовки ответа отправляются с первой строкой, поэтому ошибку, случившуюся
+// до неё, ещё можно вернуть обычным JSON.
+type exporter struct {
+	c        *gin.Context
+	format   string
+	filename string
+	columns  []string
+	csv      *csv.Writer
+	json     *json.Encoder
+	rows     int
+}
+
+func newExporter(c *gin.Context, format, filename string, columns []string) *exporter {
+	return &exporter{
+		c:        c,
+		format:   format,
+		filename: filename,
+		columns:  columns,
+	}
+}
+
+func (e *exporter) start() error {
+	e.extendDeadline()
+
+	if e.format == exportFormatCSV {
+		e.c.Header("Content-Type", "text/csv; charset=utf-8")
+		e.c.Header("Content-Disposition", `attachment; filename="`+e.filename+`.csv"`)
+		e.c.Status(http.StatusOK)
+		e.csv = csv.NewWriter(e.c.Writer)
+		return e.csv.Write(e.columns)
+	}
+
+	e.c.Header("Content-Type", "application/x-ndjson")
+	e.c.Status(http.StatusOK)
+	e.json = json.NewEncoder(e.c.Writer)
+	return nil
+}
+
+// write выводит строку: values — для CSV в порядке columns, row — для NDJSON.
+func (e *exporter) write(values []string, row interface{}) error {
+	if e.rows == 0 {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	e.rows++
+
+	var err error
+	if e.csv != nil {
+		err = e.csv.Write(values)
+	} else {
+		err = e.json.Encode(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	if e.rows%exportFlushEvery == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+func (e *exporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	e.c.Writer.Flush()
+	e.extendDeadline()
+	return nil
+}
+
+// extendDeadline продлевает срок записи ответа; если writer этого не
+// поддерживает (например, в тестах), действует WriteTimeout сервера.
+func (e *exporter) extendDeadline() {
+	_ = http.NewResponseController(e.c.Writer).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+}
+
+// finishExport завершает выгрузку. Если строки уже ушли клиенту, статус
+// изменить нельзя: ошибка только логируется и выгрузка обрывается.
+func (h *Handler) finishExport(e *exporter, err error) {
+	if err != nil {
+		if e.rows == 0 {
+			h.respondError(e.c, err)
+			return
+		}
+		h.log(e.c).Error("Export interrupted", zap.Int("rows", e.rows), zap.Error(err))
+		return
+	}
+
+	if e.rows == 0 {
+		if err := e.start(); err != nil {
+			h.log(e.c).Error("Failed to write export header", zap.Error(err))
+			return
+		}
+	}
+
+	if err := e.flush(); err != nil {
+		h.log(e.c).Error("Failed to flush export", zap.Error(err))
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"go.uber.org/zap"
 	"assign-reviewers-for-pull-requests/internal/service"
 	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
 )
 
 type Handler struct {
@@ -22,24 +24,75 @@ func NewHandler(services *service.Services, logger *zap.Logger) *Handler {
 func (h *Handler) InitRoutes(router *gin.Engine) {
 	router.GET("/health", h.healthCheck)
 
-	router.POST("/team/add", h.createTeam)
-	router.GET("/team/get", h.getTeam)
+	router.POST("/team/add", h.requireScope(model.ScopeTeamsWrite), h.createTeam)
+	router.GET("/team/get", h.requireScope(model.ScopeTeamsRead), h.getTeam)
+	router.POST("/team/addMember", h.requireScope(model.ScopeTeamsWrite), h.addTeamMember)
+	router.POST("/team/removeMember", h.requireScope(model.ScopeTeamsWrite), h.removeTeamMember)
+	router.POST("/team/moveMember", h.requireScope(model.ScopeTeamsWrite), h.moveTeamMember)
+	router.POST("/team/setMemberIsActive", h.requireScope(model.ScopeTeamsWrite), h.setTeamMemberIsActive)
+	router.POST("/team/update", h.requireScope(model.ScopeTeamsWrite), h.updateTeam)
+	router.POST("/team/setIsArchived", h.requireScope(model.ScopeTeamsWrite), h.setTeamIsArchived)
+	router.DELETE("/team/delete", h.requireScope(model.ScopeTeamsWrite), h.deleteTeam)
 
-	router.POST("/users/setIsActive", h.setIsActive)
-	router.GET("/users/getReview", h.getUserReviews)
+	router.POST("/users/setIsActive", h.requireScope(model.ScopeUsersWrite), h.setIsActive)
+	router.GET("/users/getReview", h.requireScope(model.ScopeUsersRead), h.getUserReviews)
+	router.POST("/users/setPrimaryTeam", h.requireScope(model.ScopeUsersWrite), h.setPrimaryTeam)
+	router.GET("/users/get", h.requireScope(model.ScopeUsersRead), h.getUser)
+	router.POST("/users/update", h.requireScope(model.ScopeUsersWrite), h.updateUser)
+	router.DELETE("/users/delete", h.requireScope(model.ScopeUsersWrite), h.deleteUser)
+	router.GET("/users/list", h.requireScope(model.ScopeUsersRead), h.listUsers)
+	router.GET("/users/roles", h.requireScope(model.ScopeUsersRead), h.getUserRoles)
+	router.POST("/users/grantRole", h.requireScope(model.ScopeUsersWrite), h.grantRole)
+	router.POST("/users/revokeRole", h.requireScope(model.ScopeUsersWrite), h.revokeRole)
 
-	router.POST("/pullRequest/create", h.createPR)
-	router.POST("/pullRequest/merge", h.mergePR)
-	router.POST("/pullRequest/reassign", h.reassignReviewer)
+	router.POST("/forgeUsers/map", h.requireScope(model.ScopeUsersWrite), h.mapForgeUser)
+	router.POST("/forgeUsers/unmap", h.requireScope(model.ScopeUsersWrite), h.unmapForgeUser)
+	router.GET("/forgeUsers/list", h.requireScope(model.ScopeUsersRead), h.listForgeUsers)
 
-	router.GET("/stats", h.getStats)
+	router.POST("/webhooks/github", h.githubWebhook)
+	router.POST("/webhooks/gitlab", h.gitlabWebhook)
+
+	router.POST("/pullRequest/create", h.requireScope(model.ScopePRsWrite), h.createPR)
+	router.POST("/pullRequest/merge", h.requireScope(model.ScopePRsWrite), h.mergePR)
+	router.POST("/pullRequest/close", h.requireScope(model.ScopePRsWrite), h.closePR)
+	router.POST("/pullRequest/reopen", h.requireScope(model.ScopePRsWrite), h.reopenPR)
+	router.POST("/pullRequest/reassign", h.requireScope(model.ScopePRsWrite), h.reassignReviewer)
+	router.POST("/pullRequest/review", h.requireScope(model.ScopePRsWrite), h.submitReview)
+	router.GET("/pullRequest/history", h.requireScope(model.ScopePRsRead), h.getPRHistory)
+
+	router.GET("/stats", h.requireScope(model.ScopeStatsRead), h.getStats)
+
+	router.GET("/audit", h.requireScope(model.ScopeAuditRead), h.listAuditEvents)
+
+	router.POST("/tokens/issue", h.requireScope(model.ScopeAdmin), h.issueToken)
+	router.GET("/tokens/list", h.requireScope(model.ScopeAdmin), h.listTokens)
+	router.POST("/tokens/revoke", h.requireScope(model.ScopeAdmin), h.revokeToken)
+
+	router.POST("/webhookSubscriptions/create", h.requireScope(model.ScopeAdmin), h.createWebhookSubscription)
+	router.GET("/webhookSubscriptions/list", h.requireScope(model.ScopeAdmin), h.listWebhookSubscriptions)
+	router.DELETE("/webhookSubscriptions/delete", h.requireScope(model.ScopeAdmin), h.deleteWebhookSubscription)
+	router.GET("/webhookSubscriptions/deliveries", h.requireScope(model.ScopeAdmin), h.listWebhookDeliveries)
+	router.POST("/webhookSubscriptions/redeliver", h.requireScope(model.ScopeAdmin), h.redeliverWebhookEvent)
 }
 
+// respondError пишет ошибку в теле ответа вместе с идентификатором запроса,
+// по которому её можно найти в логах.
 func (h *Handler) respondError(c *gin.Context, err error) {
-	if appErr, ok := err.(*errors.AppError); ok {
-		c.JSON(appErr.HTTPStatus, errors.ErrorResponse{Error: *appErr})
-	} else {
-		appErr := errors.ErrInternal(err)
-		c.JSON(appErr.HTTPStatus, errors.ErrorResponse{Error: *appErr})
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.ErrInternal(err)
 	}
+
+	body := *appErr
+	body.RequestID = requestctx.RequestID(c.Request.Context())
+	c.JSON(appErr.HTTPStatus, errors.ErrorResponse{Error: body})
+}
+
+// log возвращает логгер запроса с его request_id.
+func (h *Handler) log(c *gin.Context) *zap.Logger {
+	return requestctx.Logger(c.Request.Context(), h.logger)
+}
+
+func (h *Handler) badRequest(c *gin.Context, message string) {
+	h.respondError(c, errors.ErrBadRequest(message))
 }
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -12,22 +14,18 @@ func (h *Handler) createPR(c *gin.Context) {
 	var req model.CreatePRRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
 		return
 	}
 
-	pr, err := h.services.PullRequest.CreatePR(&req)
+	pr, err := h.services.PullRequest.CreatePR(c.Request.Context(), &req)
 	if err != nil {
 		h.respondError(c, err)
 		return
 	}
 
+	setPRETag(c, pr)
 	c.JSON(http.StatusCreated, gin.H{
 		"pr": pr,
 	})
@@ -37,22 +35,75 @@ func (h *Handler) mergePR(c *gin.Context) {
 	var req model.MergePRRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
 		return
 	}
 
-	pr, err := h.services.PullRequest.MergePR(req.PullRequestID)
+	expectedVersion, ok := h.expectedVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
+
+	pr, err := h.services.PullRequest.MergePR(c.Request.Context(), req.PullRequestID, expectedVersion)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	setPRETag(c, pr)
+	c.JSON(http.StatusOK, gin.H{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) closePR(c *gin.Context) {
+	var req model.ClosePRRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	expectedVersion, ok := h.expectedVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
+
+	pr, err := h.services.PullRequest.ClosePR(c.Request.Context(), req.PullRequestID, expectedVersion)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	setPRETag(c, pr)
+	c.JSON(http.StatusOK, gin.H{
+		"pr": pr,
+	})
+}
+
+func (h *Handler) reopenPR(c *gin.Context) {
+	var req model.ReopenPRRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	expectedVersion, ok := h.expectedVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
+
+	pr, err := h.services.PullRequest.ReopenPR(c.Request.Context(), req.PullRequestID, expectedVersion)
 	if err != nil {
 		h.respondError(c, err)
 		return
 	}
 
+	setPRETag(c, pr)
 	c.JSON(http.StatusOK, gin.H{
 		"pr": pr,
 	})
@@ -62,24 +113,92 @@ func (h *Handler) reassignReviewer(c *gin.Context) {
 	var req model.ReassignPRRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
 		return
 	}
 
-	pr, replacedBy, err := h.services.PullRequest.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	expectedVersion, ok := h.expectedVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
+
+	pr, replacedBy, err := h.services.PullRequest.ReassignReviewer(c.Request.Context(), req.PullRequestID, req.OldUserID, expectedVersion)
 	if err != nil {
 		h.respondError(c, err)
 		return
 	}
 
+	setPRETag(c, pr)
 	c.JSON(http.StatusOK, gin.H{
 		"pr":          pr,
 		"replaced_by": replacedBy,
 	})
+}
+
+func (h *Handler) submitReview(c *gin.Context) {
+	var req model.SubmitReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	expectedVersion, ok := h.expectedVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
+	req.ExpectedVersion = expectedVersion
+
+	decision, err := h.services.PullRequest.SubmitReview(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"review": decision,
+	})
+}
+
+func (h *Handler) getPRHistory(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	if prID == "" {
+		h.badRequest(c, "pull_request_id query parameter is required")
+		return
+	}
+
+	history, err := h.services.PullRequest.GetHistory(c.Request.Context(), prID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// expectedVersion возвращает версию PR, ожидаемую клиентом: из заголовка
+// If-Match (ETag из ответа) или из поля expected_version; 0 — не задана.
+func (h *Handler) expectedVersion(c *gin.Context, bodyVersion int) (int, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return bodyVersion, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version < 1 {
+		h.badRequest(c, "invalid If-Match: expected ETag of the pull request")
+		return 0, false
+	}
+	if bodyVersion != 0 && bodyVersion != version {
+		h.badRequest(c, "If-Match and expected_version do not match")
+		return 0, false
+	}
+
+	return version, true
+}
+
+func setPRETag(c *gin.Context, pr *model.PullRequest) {
+	c.Header("ETag", `"`+strconv.Itoa(pr.Version)+`"`)
 }
\ No newline at end of file
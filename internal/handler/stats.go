@@ -2,25 +2,127 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/model"
 )
 
+const defaultStatsListLimit = 50
+
+// defaultStatsWindow — период статистики времени ревью и равномерности, если from не указан.
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+const defaultOutlierStddev = 2.0
+
 func (h *Handler) getStats(c *gin.Context) {
-	statsType := c.Query("type")
+	var query model.StatsQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.log(c).Warn("Invalid query parameters", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
 
-	if statsType == "" {
+	if query.Type == "" {
 		c.JSON(http.StatusOK, gin.H{
 			"status": "ok",
 		})
 		return
 	}
 
-	if statsType == "users" {
-		stats, err := h.services.Stats.GetUserStats()
+	from, ok := h.parseStatsTime(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := h.parseStatsTime(c, "to")
+	if !ok {
+		return
+	}
+
+	format, ok := h.exportFormat(c)
+	if !ok {
+		return
+	}
+
+	if format != "" && query.Type != "users" && query.Type != "prs" {
+		h.badRequest(c, "export is supported only for users and prs stats")
+		return
+	}
+
+	// Выгрузка по умолчанию отдаёт все строки, пагинация — только по запросу.
+	if query.Limit == 0 && format == "" {
+		query.Limit = defaultStatsListLimit
+	}
+
+	filter := model.StatsFilter{
+		From:    from,
+		To:      to,
+		Status:  query.Status,
+		GroupBy: query.GroupBy,
+		Limit:   query.Limit,
+		Offset:  query.Offset,
+	}
+
+	if query.Type == "users" && format != "" {
+		e := newExporter(c, format, "user_stats", []string{"bucket", "user_id", "assignment_count"})
+		err := h.services.Stats.ExportUserStats(c.Request.Context(), query.TeamName, filter, func(stat model.UserAssignmentStat) error {
+			return e.write([]string{
+				formatBucket(stat.Bucket), stat.UserID, strconv.Itoa(stat.AssignmentCount),
+			}, stat)
+		})
+		h.finishExport(e, err)
+		return
+	}
+
+	if query.Type == "prs" && format != "" {
+		e := newExporter(c, format, "pr_stats", []string{"bucket", "pr_id", "reviewer_count"})
+		err := h.services.Stats.ExportPRStats(c.Request.Context(), query.TeamName, filter, func(stat model.PRReviewerStat) error {
+			return e.write([]string{
+				formatBucket(stat.Bucket), stat.PRID, strconv.Itoa(stat.ReviewerCount),
+			}, stat)
+		})
+		h.finishExport(e, err)
+		return
+	}
+
+	if query.Type == "users" {
+		stats, err := h.services.Stats.GetUserStats(c.Request.Context(), query.TeamName, filter)
+		if err != nil {
+			h.log(c).Error("Failed to get user stats", zap.Error(err))
+			h.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	if query.Type == "prs" {
+		stats, err := h.services.Stats.GetPRStats(c.Request.Context(), query.TeamName, filter)
+		if err != nil {
+			h.log(c).Error("Failed to get PR stats", zap.Error(err))
+			h.respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	if query.Type == "turnaround" || query.Type == "fairness" {
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		if from.IsZero() {
+			from = to.Add(-defaultStatsWindow)
+		}
+	}
+
+	if query.Type == "turnaround" {
+		stats, err := h.services.Stats.GetTurnaroundStats(c.Request.Context(), from, to)
 		if err != nil {
-			h.logger.Error("Failed to get user stats", zap.Error(err))
+			h.log(c).Error("Failed to get turnaround stats", zap.Error(err))
 			h.respondError(c, err)
 			return
 		}
@@ -30,23 +132,53 @@ func (h *Handler) getStats(c *gin.Context) {
 		return
 	}
 
-	if statsType == "prs" {
-		stats, err := h.services.Stats.GetPRStats()
+	if query.Type == "fairness" {
+		if query.OutlierStddev == 0 {
+			query.OutlierStddev = defaultOutlierStddev
+		}
+
+		report, err := h.services.Stats.GetFairnessReport(c.Request.Context(), query.TeamName, from, to, query.OutlierStddev)
 		if err != nil {
-			h.logger.Error("Failed to get PR stats", zap.Error(err))
+			h.log(c).Error("Failed to get fairness report", zap.Error(err))
 			h.respondError(c, err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{
-			"stats": stats,
+			"stats": report,
 		})
 		return
 	}
 
-	c.JSON(http.StatusBadRequest, gin.H{
-		"error": gin.H{
-			"code":    "BAD_REQUEST",
-			"message": "invalid stats type",
-		},
-	})
+	h.badRequest(c, "invalid stats type")
+}
+
+// parseStatsTime разбирает параметр периода в RFC3339 или YYYY-MM-DD.
+// Дата без времени в to включает весь день. Пустой параметр — нулевое время.
+func (h *Handler) parseStatsTime(c *gin.Context, param string) (time.Time, bool) {
+	raw := c.Query(param)
+	if raw == "" {
+		return time.Time{}, true
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), true
+	}
+
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		h.badRequest(c, "invalid " + param + ": expected RFC3339 or YYYY-MM-DD")
+		return time.Time{}, false
+	}
+
+	if param == "to" {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+func formatBucket(bucket *time.Time) string {
+	if bucket == nil {
+		return ""
+	}
+	return bucket.Format(time.RFC3339)
 }
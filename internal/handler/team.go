@@ -11,17 +11,12 @@ func (h *Handler) createTeam(c *gin.Context) {
 	var req model.Team
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
 		return
 	}
 
-	team, err := h.services.Team.CreateTeam(&req)
+	team, err := h.services.Team.CreateTeam(c.Request.Context(), &req)
 	if err != nil {
 		h.respondError(c, err)
 		return
@@ -35,20 +30,156 @@ func (h *Handler) createTeam(c *gin.Context) {
 func (h *Handler) getTeam(c *gin.Context) {
 	teamName := c.Query("team_name")
 	if teamName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": "team_name query parameter is required",
-			},
-		})
+		h.badRequest(c, "team_name query parameter is required")
 		return
 	}
 
-	team, err := h.services.Team.GetTeam(teamName)
+	var team *model.Team
+	var err error
+	if c.Query("include_descendants") == "true" {
+		team, err = h.services.Team.GetTeamTree(c.Request.Context(), teamName)
+	} else {
+		team, err = h.services.Team.GetTeam(c.Request.Context(), teamName)
+	}
 	if err != nil {
 		h.respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, team)
-}
\ No newline at end of file
+}
+
+func (h *Handler) addTeamMember(c *gin.Context) {
+	var req model.AddTeamMemberRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	team, err := h.services.Team.AddMember(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team": team,
+	})
+}
+
+func (h *Handler) removeTeamMember(c *gin.Context) {
+	var req model.RemoveTeamMemberRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	team, err := h.services.Team.RemoveMember(c.Request.Context(), req.TeamName, req.UserID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team": team,
+	})
+}
+
+func (h *Handler) moveTeamMember(c *gin.Context) {
+	var req model.MoveTeamMemberRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.services.Team.MoveMember(c.Request.Context(), req.UserID, req.FromTeamName, req.ToTeamName)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+func (h *Handler) updateTeam(c *gin.Context) {
+	var req model.UpdateTeamRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	team, err := h.services.Team.UpdateTeam(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team": team,
+	})
+}
+
+func (h *Handler) setTeamIsArchived(c *gin.Context) {
+	var req model.SetIsArchivedRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	team, err := h.services.Team.SetIsArchived(c.Request.Context(), req.TeamName, req.IsArchived)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team": team,
+	})
+}
+
+func (h *Handler) deleteTeam(c *gin.Context) {
+	teamName := c.Query("team_name")
+	if teamName == "" {
+		h.badRequest(c, "team_name query parameter is required")
+		return
+	}
+
+	if err := h.services.Team.DeleteTeam(c.Request.Context(), teamName, c.Query("target_team_name")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) setTeamMemberIsActive(c *gin.Context) {
+	var req model.SetMemberIsActiveRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	team, err := h.services.Team.SetMemberIsActive(c.Request.Context(), req.TeamName, req.UserID, req.IsActive)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"team": team,
+	})
+}
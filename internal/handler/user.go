@@ -7,21 +7,18 @@ import (
 	"assign-reviewers-for-pull-requests/internal/model"
 )
 
+const defaultUserListLimit = 50
+
 func (h *Handler) setIsActive(c *gin.Context) {
 	var req model.SetIsActiveRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
 		return
 	}
 
-	user, err := h.services.User.SetIsActive(req.UserID, req.IsActive)
+	user, err := h.services.User.SetIsActive(c.Request.Context(), req.UserID, req.IsActive)
 	if err != nil {
 		h.respondError(c, err)
 		return
@@ -35,16 +32,25 @@ func (h *Handler) setIsActive(c *gin.Context) {
 func (h *Handler) getUserReviews(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": gin.H{
-				"code":    "BAD_REQUEST",
-				"message": "user_id query parameter is required",
-			},
+		h.badRequest(c, "user_id query parameter is required")
+		return
+	}
+
+	format, ok := h.exportFormat(c)
+	if !ok {
+		return
+	}
+
+	if format != "" {
+		e := newExporter(c, format, "reviews", []string{"pull_request_id", "pull_request_name", "author_id", "status"})
+		err := h.services.User.ExportReviews(c.Request.Context(), userID, func(pr model.PullRequestShort) error {
+			return e.write([]string{pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status}, pr)
 		})
+		h.finishExport(e, err)
 		return
 	}
 
-	prs, err := h.services.User.GetReviews(userID)
+	prs, err := h.services.User.GetReviews(c.Request.Context(), userID)
 	if err != nil {
 		h.respondError(c, err)
 		return
@@ -54,4 +60,151 @@ func (h *Handler) getUserReviews(c *gin.Context) {
 		"user_id":       userID,
 		"pull_requests": prs,
 	})
-}
\ No newline at end of file
+}
+
+func (h *Handler) setPrimaryTeam(c *gin.Context) {
+	var req model.SetPrimaryTeamRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.services.User.SetPrimaryTeam(c.Request.Context(), req.UserID, req.TeamName)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+func (h *Handler) getUser(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		h.badRequest(c, "user_id query parameter is required")
+		return
+	}
+
+	user, err := h.services.User.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+func (h *Handler) updateUser(c *gin.Context) {
+	var req model.UpdateUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.services.User.UpdateUser(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user,
+	})
+}
+
+func (h *Handler) deleteUser(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		h.badRequest(c, "user_id query parameter is required")
+		return
+	}
+
+	if err := h.services.User.DeleteUser(c.Request.Context(), userID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) listUsers(c *gin.Context) {
+	var query model.ListUsersQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.log(c).Warn("Invalid query parameters", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultUserListLimit
+	}
+
+	users, err := h.services.User.ListUsers(c.Request.Context(), query.TeamName, query.IsActive, query.Limit, query.Offset)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+func (h *Handler) grantRole(c *gin.Context) {
+	var req model.RoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	roles, err := h.services.Role.GrantRole(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *Handler) revokeRole(c *gin.Context) {
+	var req model.RoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	roles, err := h.services.Role.RevokeRole(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *Handler) getUserRoles(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		h.badRequest(c, "user_id query parameter is required")
+		return
+	}
+
+	roles, err := h.services.Role.ListRoles(c.Request.Context(), userID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
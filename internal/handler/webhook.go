@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+// maxWebhookBodySize — GitHub не присылает тела больше 25 МБ.
+const maxWebhookBodySize = 25 << 20
+
+// githubWebhook принимает события GitHub. Маршрут не требует токена:
+// запрос подтверждается подписью X-Hub-Signature-256.
+func (h *Handler) githubWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		h.log(c).Warn("Failed to read webhook body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.services.Forge.HandleGitHubEvent(c.Request.Context(), &model.WebhookDelivery{
+		Event:      c.GetHeader("X-GitHub-Event"),
+		DeliveryID: c.GetHeader("X-GitHub-Delivery"),
+		Signature:  c.GetHeader("X-Hub-Signature-256"),
+		Body:       body,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// gitlabWebhook принимает события GitLab. Маршрут не требует токена API:
+// запрос подтверждается секретом X-Gitlab-Token.
+func (h *Handler) gitlabWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		h.log(c).Warn("Failed to read webhook body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	result, err := h.services.Forge.HandleGitLabEvent(c.Request.Context(), &model.WebhookDelivery{
+		Event:      c.GetHeader("X-Gitlab-Event"),
+		DeliveryID: c.GetHeader("X-Gitlab-Event-UUID"),
+		Signature:  c.GetHeader("X-Gitlab-Token"),
+		Body:       body,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) mapForgeUser(c *gin.Context) {
+	var req model.MapForgeUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	mapping, err := h.services.Forge.MapUser(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mapping": mapping,
+	})
+}
+
+func (h *Handler) unmapForgeUser(c *gin.Context) {
+	var req model.UnmapForgeUserRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	if err := h.services.Forge.UnmapUser(c.Request.Context(), req.Forge, req.Login); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) listForgeUsers(c *gin.Context) {
+	forge := c.Query("forge")
+	if forge != "" && forge != model.ForgeGitHub && forge != model.ForgeGitLab {
+		h.badRequest(c, "unknown forge "+forge)
+		return
+	}
+
+	mappings, err := h.services.Forge.ListUserMappings(c.Request.Context(), forge)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"mappings": mappings,
+	})
+}
+
+const defaultDeliveryListLimit = 50
+
+func (h *Handler) createWebhookSubscription(c *gin.Context) {
+	var req model.CreateWebhookSubscriptionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	sub, err := h.services.Webhook.CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"subscription": sub,
+	})
+}
+
+func (h *Handler) listWebhookSubscriptions(c *gin.Context) {
+	subs, err := h.services.Webhook.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions": subs,
+	})
+}
+
+func (h *Handler) deleteWebhookSubscription(c *gin.Context) {
+	var query model.DeleteWebhookSubscriptionQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.log(c).Warn("Invalid query parameters", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	if err := h.services.Webhook.DeleteSubscription(c.Request.Context(), query.SubscriptionID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) listWebhookDeliveries(c *gin.Context) {
+	var query model.ListEventDeliveriesQuery
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.log(c).Warn("Invalid query parameters", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultDeliveryListLimit
+	}
+
+	deliveries, err := h.services.Webhook.ListDeliveries(c.Request.Context(), model.EventDeliveryFilter{
+		SubscriptionID: query.SubscriptionID,
+		EventType:      query.EventType,
+		Status:         query.Status,
+		Limit:          query.Limit,
+		Offset:         query.Offset,
+	})
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *Handler) redeliverWebhookEvent(c *gin.Context) {
+	var req model.RedeliverEventRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log(c).Warn("Invalid request body", zap.Error(err))
+		h.badRequest(c, err.Error())
+		return
+	}
+
+	delivery, err := h.services.Webhook.Redeliver(c.Request.Context(), req.DeliveryID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,202 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func (k *testKeys) jwks() []byte {
+	enc := base64.RawURLEncoding.EncodeToString
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": enc(k.rsa.N.Bytes()),
+				"e": enc(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256",
+				"x": enc(k.ec.X.FillBytes(make([]byte, 32))),
+				"y": enc(k.ec.Y.FillBytes(make([]byte, 32))),
+			},
+		},
+	})
+	return data
+}
+
+func (k *testKeys) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   "https://issuer.example",
+		"aud":   []string{"other", "pr-reviewer"},
+		"sub":   "subject-1",
+		"login": "u1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "prs:write stats:read",
+	}
+}
+
+func newTestVerifier(t *testing.T, keys *testKeys) *Verifier {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keys.jwks(), 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	verifier, err := NewVerifier(context.Background(), NewFileKeySource(path), Config{
+		Issuer:    "https://issuer.example",
+		Audience:  "pr-reviewer",
+		UserClaim: "login",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	return verifier
+}
+
+func TestVerify_ValidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier := newTestVerifier(t, keys)
+
+	for _, tc := range []struct{ alg, kid string }{{"RS256", "rsa-1"}, {"ES256", "ec-1"}} {
+		claims, err := verifier.Verify(context.Background(), keys.sign(t, tc.alg, tc.kid, validClaims()))
+		if err != nil {
+			t.Fatalf("%s: expected valid token, got %v", tc.alg, err)
+		}
+		if claims.UserID != "u1" || claims.Subject != "subject-1" {
+			t.Errorf("%s: unexpected claims %+v", tc.alg, claims)
+		}
+		if strings.Join(claims.Scopes, " ") != "prs:write stats:read" {
+			t.Errorf("%s: unexpected scopes %v", tc.alg, claims.Scopes)
+		}
+	}
+}
+
+func TestVerify_RejectsInvalidTokens(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier := newTestVerifier(t, keys)
+
+	with := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	valid := keys.sign(t, "RS256", "rsa-1", validClaims())
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"login":"admin"}`)) + "." + parts[2]
+
+	cases := map[string]string{
+		"expired":        keys.sign(t, "RS256", "rsa-1", with("exp", time.Now().Add(-time.Hour).Unix())),
+		"no exp":         keys.sign(t, "RS256", "rsa-1", with("exp", nil)),
+		"not yet valid":  keys.sign(t, "RS256", "rsa-1", with("nbf", time.Now().Add(time.Hour).Unix())),
+		"wrong issuer":   keys.sign(t, "RS256", "rsa-1", with("iss", "https://evil.example")),
+		"wrong audience": keys.sign(t, "RS256", "rsa-1", with("aud", "other")),
+		"no user claim":  keys.sign(t, "RS256", "rsa-1", with("login", nil)),
+		"alg mismatch":   keys.sign(t, "ES256", "rsa-1", validClaims()),
+		"unknown kid":    keys.sign(t, "RS256", "rsa-2", validClaims()),
+		"tampered":       tampered,
+		"malformed":      "not-a-jwt",
+	}
+
+	for name, token := range cases {
+		if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestVerify_ReloadsKeysFromURLOnRotation(t *testing.T) {
+	oldKeys := newTestKeys(t)
+	newKeys := newTestKeys(t)
+
+	current := oldKeys.jwks()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(current)
+	}))
+	defer server.Close()
+
+	verifier, err := NewVerifier(context.Background(), NewURLKeySource(server.URL, time.Second), Config{
+		Issuer:    "https://issuer.example",
+		Audience:  "pr-reviewer",
+		UserClaim: "login",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+
+	// Издатель сменил ключи с тем же kid
+	current = newKeys.jwks()
+	token := newKeys.sign(t, "RS256", "rsa-1", validClaims())
+
+	if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Expected old keys to reject token, got %v", err)
+	}
+
+	verifier.now = func() time.Time { return time.Now().Add(2 * minRefreshInterval) }
+	verifier.cfg.Refresh = minRefreshInterval
+
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Errorf("Expected reloaded keys to accept token, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken — токен не прошёл проверку; остальные ошибки Verify
+// означают сбой загрузки ключей.
+var ErrInvalidToken = errors.New("invalid token")
+
+// minRefreshInterval ограничивает перезагрузку JWKS из-за неизвестного kid,
+// чтобы запросы с произвольным kid не нагружали источник ключей.
+const minRefreshInterval = time.Minute
+
+type Config struct {
+	Issuer    string
+	Audience  string
+	UserClaim string        // claim со значением users.user_id
+	Leeway    time.Duration // допустимое расхождение часов
+	Refresh   time.Duration // как часто перечитывать JWKS
+}
+
+// Claims — проверенные данные токена.
+type Claims struct {
+	ID      string
+	Subject string
+	UserID  string
+	Scopes  []string
+}
+
+// Verifier проверяет JWT с подписью RS256 или ES256.
+type Verifier struct {
+	source KeySource
+	cfg    Config
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	keys     KeySet
+	loadedAt time.Time
+}
+
+// NewVerifier сразу загружает ключи, чтобы ошибка конфигурации была видна
+// при старте.
+func NewVerifier(ctx context.Context, source KeySource, cfg Config) (*Verifier, error) {
+	v := &Verifier{
+		source: source,
+		cfg:    cfg,
+		now:    time.Now,
+	}
+	if err := v.reload(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, invalid("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, invalid("malformed header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed signature")
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch header.Alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok || rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return nil, invalid("bad signature")
+		}
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return nil, invalid("bad signature")
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return nil, invalid("bad signature")
+		}
+	default:
+		return nil, invalid(fmt.Sprintf("unsupported alg %q", header.Alg))
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, invalid("malformed claims")
+	}
+
+	return v.checkClaims(claims)
+}
+
+func (v *Verifier) checkClaims(claims map[string]interface{}) (*Claims, error) {
+	now := v.now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return nil, invalid("exp is required")
+	}
+	if now.After(exp.Add(v.cfg.Leeway)) {
+		return nil, invalid("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(v.cfg.Leeway).Before(nbf) {
+		return nil, invalid("token is not valid yet")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return nil, invalid("unexpected issuer")
+	}
+	if !hasAudience(claims["aud"], v.cfg.Audience) {
+		return nil, invalid("unexpected audience")
+	}
+
+	userID, _ := claims[v.cfg.UserClaim].(string)
+	if userID == "" {
+		return nil, invalid(fmt.Sprintf("claim %q is required", v.cfg.UserClaim))
+	}
+
+	result := &Claims{UserID: userID, Scopes: scopes(claims)}
+	result.ID, _ = claims["jti"].(string)
+	result.Subject, _ = claims["sub"].(string)
+	return result, nil
+}
+
+// key возвращает ключ по kid. Если ключа нет или JWKS устарел, ключи
+// перечитываются: так подхватывается ротация у издателя.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.RLock()
+	key, found := lookup(v.keys, kid)
+	age := v.now().Sub(v.loadedAt)
+	v.mu.RUnlock()
+
+	switch {
+	case found && (v.cfg.Refresh == 0 || age < v.cfg.Refresh):
+		return key, nil
+	case !found && age < minRefreshInterval:
+		return nil, invalid("unknown signing key")
+	}
+
+	if err := v.reload(ctx); err != nil {
+		if found {
+			// Источник недоступен — работаем со старыми ключами.
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.RLock()
+	key, found = lookup(v.keys, kid)
+	v.mu.RUnlock()
+	if !found {
+		return nil, invalid("unknown signing key")
+	}
+	return key, nil
+}
+
+func (v *Verifier) reload(ctx context.Context) error {
+	keys, err := v.source.Load(ctx)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// Время обновляется и при ошибке, чтобы не обращаться к источнику
+	// на каждый запрос.
+	v.loadedAt = v.now()
+	if err != nil {
+		return err
+	}
+	v.keys = keys
+	return nil
+}
+
+// lookup без kid допускает только JWKS из одного ключа.
+func lookup(keys KeySet, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+func decodeSegment(segment string, dest interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(dest)
+}
+
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scopes читает области доступа из claim scope (строка через пробел) или scp
+// (массив).
+func scopes(claims map[string]interface{}) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var result []string
+	if scp, ok := claims["scp"].([]interface{}); ok {
+		for _, item := range scp {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+func invalid(reason string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, reason)
+}
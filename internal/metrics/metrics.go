@@ -0,0 +1,346 @@
+// Package metrics — минимальная реализация метрик в текстовом формате
+// Prometheus: счётчики, гистограммы и метрики, вычисляемые при сборе.
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets — границы гистограмм длительности в секундах.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default — реестр, который отдаёт эндпоинт /metrics.
+var Default = NewRegistry()
+
+// Sample — значение метрики, вычисляемой при сборе.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type collector interface {
+	name() string
+	write(ctx context.Context, w *bufio.Writer) error
+}
+
+// Registry хранит метрики в порядке регистрации.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+	names      map[string]bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[c.name()] {
+		panic(fmt.Sprintf("metrics: duplicate metric %q", c.name()))
+	}
+	r.names[c.name()] = true
+	r.collectors = append(r.collectors, c)
+}
+
+// NewCounterVec регистрирует счётчик с указанными метками.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		metric: metric{metricName: name, help: help, labels: labels},
+		values: make(map[string]*series),
+	}
+	r.register(c)
+	return c
+}
+
+// NewHistogramVec регистрирует гистограмму с указанными границами и метками.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		metric:  metric{metricName: name, help: help, labels: labels},
+		buckets: append([]float64{}, buckets...),
+		values:  make(map[string]*histogramSeries),
+	}
+	sort.Float64s(h.buckets)
+	r.register(h)
+	return h
+}
+
+// NewGaugeFunc регистрирует gauge, значения которого вычисляются при каждом сборе.
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func(ctx context.Context) ([]Sample, error)) {
+	r.register(&funcCollector{
+		metric:  metric{metricName: name, help: help, labels: labels},
+		typ:     "gauge",
+		collect: collect,
+	})
+}
+
+// NewCounterFunc регистрирует счётчик, значения которого берутся из внешнего источника.
+func (r *Registry) NewCounterFunc(name, help string, labels []string, collect func(ctx context.Context) ([]Sample, error)) {
+	r.register(&funcCollector{
+		metric:  metric{metricName: name, help: help, labels: labels},
+		typ:     "counter",
+		collect: collect,
+	})
+}
+
+// Write выводит все метрики в текстовом формате Prometheus.
+func (r *Registry) Write(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		if err := c.write(ctx, bw); err != nil {
+			return fmt.Errorf("collect %s: %w", c.name(), err)
+		}
+	}
+	return bw.Flush()
+}
+
+// Handler отдаёт метрики реестра. Если какую-то метрику собрать не удалось,
+// возвращается 500, чтобы неполные данные не выглядели нулями.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var buf bytes.Buffer
+		if err := r.Write(req.Context(), &buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+type metric struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (m *metric) name() string {
+	return m.metricName
+}
+
+func (m *metric) writeHeader(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", m.metricName, escapeHelp(m.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.metricName, typ)
+}
+
+func (m *metric) key(labelValues []string) string {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", m.metricName, len(m.labels), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\xff")
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// CounterVec — монотонный счётчик с метками.
+type CounterVec struct {
+	metric
+	mu     sync.Mutex
+	values map[string]*series
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.metricName))
+	}
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.values[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...)}
+		c.values[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(ctx context.Context, w *bufio.Writer) error {
+	c.writeHeader(w, "counter")
+
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, s := range c.values {
+		samples = append(samples, Sample{LabelValues: s.labelValues, Value: s.value})
+	}
+	c.mu.Unlock()
+
+	writeSamples(w, c.metricName, c.labels, samples)
+	return nil
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// HistogramVec — гистограмма с метками.
+type HistogramVec struct {
+	metric
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramSeries
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string{}, labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(ctx context.Context, w *bufio.Writer) error {
+	h.writeHeader(w, "histogram")
+
+	h.mu.Lock()
+	all := make([]histogramSeries, 0, len(h.values))
+	for _, s := range h.values {
+		copied := *s
+		copied.counts = append([]uint64{}, s.counts...)
+		all = append(all, copied)
+	}
+	h.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool {
+		return lessLabels(all[i].labelValues, all[j].labelValues)
+	})
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, s := range all {
+		for i, bound := range h.buckets {
+			values := append(append([]string{}, s.labelValues...), formatValue(bound))
+			writeLine(w, h.metricName+"_bucket", bucketLabels, values, float64(s.counts[i]))
+		}
+		values := append(append([]string{}, s.labelValues...), "+Inf")
+		writeLine(w, h.metricName+"_bucket", bucketLabels, values, float64(s.count))
+		writeLine(w, h.metricName+"_sum", h.labels, s.labelValues, s.sum)
+		writeLine(w, h.metricName+"_count", h.labels, s.labelValues, float64(s.count))
+	}
+	return nil
+}
+
+type funcCollector struct {
+	metric
+	typ     string
+	collect func(ctx context.Context) ([]Sample, error)
+}
+
+func (f *funcCollector) write(ctx context.Context, w *bufio.Writer) error {
+	samples, err := f.collect(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range samples {
+		f.key(s.LabelValues)
+	}
+
+	f.writeHeader(w, f.typ)
+	writeSamples(w, f.metricName, f.labels, samples)
+	return nil
+}
+
+func writeSamples(w *bufio.Writer, name string, labels []string, samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		return lessLabels(samples[i].LabelValues, samples[j].LabelValues)
+	})
+	for _, s := range samples {
+		writeLine(w, name, labels, s.LabelValues, s.Value)
+	}
+}
+
+func writeLine(w *bufio.Writer, name string, labels, values []string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+func lessLabels(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
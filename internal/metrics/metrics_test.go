@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WritesTextFormat(t *testing.T) {
+	r := NewRegistry()
+
+	created := r.NewCounterVec("prs_created_total", "Created PRs.")
+	created.Inc()
+	created.Add(2)
+
+	reassigned := r.NewCounterVec("reassignments_total", "Reassignments.", "reason")
+	reassigned.Inc("STALE")
+	reassigned.Inc("REASSIGN")
+	reassigned.Inc("STALE")
+
+	latency := r.NewHistogramVec("request_seconds", "Latency.", []float64{0.1, 1}, "route")
+	latency.Observe(0.05, "/a")
+	latency.Observe(0.5, "/a")
+	latency.Observe(5, "/a")
+
+	r.NewGaugeFunc("open_reviews", "Open reviews.", []string{"team", "user_id"}, func(ctx context.Context) ([]Sample, error) {
+		return []Sample{{LabelValues: []string{"back\"end", "u1"}, Value: 3}}, nil
+	})
+
+	var buf bytes.Buffer
+	if err := r.Write(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+
+	expected := `# HELP prs_created_total Created PRs.
+# TYPE prs_created_total counter
+prs_created_total 3
+# HELP reassignments_total Reassignments.
+# TYPE reassignments_total counter
+reassignments_total{reason="REASSIGN"} 1
+reassignments_total{reason="STALE"} 2
+# HELP request_seconds Latency.
+# TYPE request_seconds histogram
+request_seconds_bucket{route="/a",le="0.1"} 1
+request_seconds_bucket{route="/a",le="1"} 2
+request_seconds_bucket{route="/a",le="+Inf"} 3
+request_seconds_sum{route="/a"} 5.55
+request_seconds_count{route="/a"} 3
+# HELP open_reviews Open reviews.
+# TYPE open_reviews gauge
+open_reviews{team="back\"end",user_id="u1"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestRegistry_HandlerFailsOnCollectError(t *testing.T) {
+	r := NewRegistry()
+	r.NewGaugeFunc("broken", "Broken.", nil, func(ctx context.Context) ([]Sample, error) {
+		return nil, errors.New("db is down")
+	})
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "db is down") {
+		t.Errorf("Expected 500 with collect error, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "Dup.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate metric")
+		}
+	}()
+	r.NewCounterVec("dup_total", "Dup.")
+}
@@ -2,22 +2,64 @@ package model
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type User struct {
-	ID       string `db:"id" json:"-"`
-	UserID   string `db:"user_id" json:"user_id"`
-	Username string `db:"username" json:"username"`
-	TeamID   string `db:"team_id" json:"-"`
-	TeamName string `db:"team_name" json:"team_name"`
-	IsActive bool   `db:"is_active" json:"is_active"`
+	ID           string           `db:"id" json:"-"`
+	UserID       string           `db:"user_id" json:"user_id"`
+	Username     string           `db:"username" json:"username"`
+	TeamID       string           `db:"team_id" json:"-"`
+	TeamName     string           `db:"team_name" json:"team_name"`
+	TeamArchived bool             `db:"team_archived" json:"-"`
+	IsActive     bool             `db:"is_active" json:"is_active"`
+	Attributes   Attributes       `db:"attributes" json:"attributes,omitempty"`
+	Teams        []TeamMembership `json:"teams,omitempty"`
+}
+
+// Attributes — произвольные атрибуты пользователя, хранятся в JSONB.
+type Attributes map[string]interface{}
+
+func (a Attributes) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (a *Attributes) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return fmt.Errorf("cannot scan %T into Attributes", src)
+	}
 }
 
 type Team struct {
-	ID       string       `db:"id" json:"-"`
-	TeamName string       `json:"team_name" binding:"required"`
-	Members  []TeamMember `json:"members" binding:"required,dive"`
+	ID             string       `db:"id" json:"-"`
+	TeamName       string       `json:"team_name" binding:"required"`
+	ParentTeamName string       `json:"parent_team_name,omitempty"`
+	MaxOpenReviews *int         `json:"max_open_reviews,omitempty"`
+	ReviewSLAHours *int         `json:"review_sla_hours,omitempty"`
+	StalePolicy    string       `json:"stale_policy,omitempty" binding:"omitempty,oneof=NUDGE ADD_REVIEWER REASSIGN"`
+	IsArchived     bool         `json:"is_archived"`
+	Members        []TeamMember `json:"members" binding:"required,dive"`
+	SubTeams       []Team       `json:"sub_teams,omitempty"`
 }
 
 type TeamMember struct {
@@ -26,12 +68,32 @@ type TeamMember struct {
 	IsActive bool   `json:"is_active"`
 }
 
+type TeamNode struct {
+	ID             string        `db:"id"`
+	TeamName       string        `db:"team_name"`
+	ParentTeamID   string        `db:"parent_team_id"`
+	MaxOpenReviews sql.NullInt64 `db:"max_open_reviews"`
+	IsArchived     bool          `db:"is_archived"`
+	Depth          int           `db:"depth"`
+}
+
+type TeamMembership struct {
+	TeamID       string `db:"team_id" json:"-"`
+	TeamName     string `db:"team_name" json:"team_name"`
+	TeamArchived bool   `db:"team_archived" json:"-"`
+	IsPrimary    bool   `db:"is_primary" json:"is_primary"`
+	IsActive     bool   `db:"is_active" json:"is_active"`
+}
+
 type PullRequest struct {
 	ID                string       `db:"id" json:"-"`
 	PullRequestID     string       `db:"pull_request_id" json:"pull_request_id"`
 	PullRequestName   string       `db:"pull_request_name" json:"pull_request_name"`
 	AuthorID          string       `db:"author_id" json:"author_id"`
+	TeamID            string       `db:"team_id" json:"-"`
+	TeamName          string       `db:"team_name" json:"team_name,omitempty"`
 	Status            string       `db:"status" json:"status"`
+	Version           int          `db:"version" json:"version"`
 	CreatedAt         time.Time    `db:"created_at" json:"createdAt,omitempty"`
 	MergedAt          sql.NullTime `db:"merged_at" json:"mergedAt,omitempty"`
 	AssignedReviewers []string     `json:"assigned_reviewers"`
@@ -44,22 +106,660 @@ type PullRequestShort struct {
 	Status          string `db:"status" json:"status"`
 }
 
+// Причины назначения и снятия ревьювера в истории PR.
+const (
+	ReviewerActionCreate       = "CREATE"
+	ReviewerActionReassign     = "REASSIGN"
+	ReviewerActionDeactivation = "DEACTIVATION"
+	ReviewerActionTeamChange   = "TEAM_CHANGE"
+	ReviewerActionUserDeleted  = "USER_DELETED"
+	ReviewerActionStale        = "STALE"
+)
+
+// Политики команды для ревью, просроченных дольше SLA.
+const (
+	StalePolicyNudge       = "NUDGE"
+	StalePolicyAddReviewer = "ADD_REVIEWER"
+	StalePolicyReassign    = "REASSIGN"
+)
+
+type StaleReview struct {
+	PRInternalID       string     `db:"pr_internal_id" json:"-"`
+	PullRequestID      string     `db:"pull_request_id" json:"pull_request_id"`
+	PullRequestName    string     `db:"pull_request_name" json:"pull_request_name"`
+	AuthorInternalID   string     `db:"author_internal_id" json:"-"`
+	TeamID             string     `db:"team_id" json:"-"`
+	TeamName           string     `db:"team_name" json:"team_name"`
+	ReviewerInternalID string     `db:"reviewer_internal_id" json:"-"`
+	ReviewerUserID     string     `db:"reviewer_user_id" json:"reviewer_id"`
+	AssignedAt         time.Time  `db:"assigned_at" json:"assigned_at"`
+	EscalatedAt        *time.Time `db:"escalated_at" json:"escalated_at,omitempty"`
+	Policy             string     `db:"stale_policy" json:"policy"`
+	SLAHours           int        `db:"sla_hours" json:"sla_hours"`
+}
+
+type StaleSweepResult struct {
+	Acquired   bool `json:"acquired"`
+	Nudged     int  `json:"nudged"`
+	Added      int  `json:"added"`
+	Reassigned int  `json:"reassigned"`
+	Failed     int  `json:"failed"`
+}
+
+type ReviewerAssignment struct {
+	UserID         string     `db:"user_id" json:"user_id"`
+	AssignedAt     time.Time  `db:"assigned_at" json:"assigned_at"`
+	AssignedAction string     `db:"assigned_action" json:"assigned_action"`
+	RemovedAt      *time.Time `db:"removed_at" json:"removed_at,omitempty"`
+	RemovedAction  string     `db:"removed_action" json:"removed_action,omitempty"`
+}
+
+type PullRequestHistory struct {
+	PullRequestID string               `json:"pull_request_id"`
+	Hops          int                  `json:"hops"`
+	Assignments   []ReviewerAssignment `json:"assignments"`
+}
+
 type CreatePRRequest struct {
 	PullRequestID   string `json:"pull_request_id" binding:"required"`
 	PullRequestName string `json:"pull_request_name" binding:"required"`
 	AuthorID        string `json:"author_id" binding:"required"`
+	TeamName        string `json:"team_name"`
 }
 
 type MergePRRequest struct {
-	PullRequestID string `json:"pull_request_id" binding:"required"`
+	PullRequestID   string `json:"pull_request_id" binding:"required"`
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"`
+}
+
+type ClosePRRequest struct {
+	PullRequestID   string `json:"pull_request_id" binding:"required"`
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"`
+}
+
+type ReopenPRRequest struct {
+	PullRequestID   string `json:"pull_request_id" binding:"required"`
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"`
 }
 
 type ReassignPRRequest struct {
+	PullRequestID   string `json:"pull_request_id" binding:"required"`
+	OldUserID       string `json:"old_user_id" binding:"required"`
+	ExpectedVersion int    `json:"expected_version" binding:"omitempty,min=1"`
+}
+
+type SubmitReviewRequest struct {
 	PullRequestID string `json:"pull_request_id" binding:"required"`
-	OldUserID     string `json:"old_user_id" binding:"required"`
+	ReviewerID    string `json:"reviewer_id" binding:"required"`
+	Decision      string `json:"decision" binding:"required,oneof=APPROVED CHANGES_REQUESTED"`
+	// ExpectedVersion — версия PR, которую видел ревьювер; 0 — без проверки.
+	ExpectedVersion int `json:"expected_version" binding:"omitempty,min=1"`
+}
+
+// Решения ревьювера по PR.
+const (
+	ReviewDecisionApproved         = "APPROVED"
+	ReviewDecisionChangesRequested = "CHANGES_REQUESTED"
+)
+
+type ReviewDecision struct {
+	PullRequestID string    `db:"pull_request_id" json:"pull_request_id"`
+	ReviewerID    string    `db:"reviewer_id" json:"reviewer_id"`
+	Decision      string    `db:"decision" json:"decision"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
 }
 
 type SetIsActiveRequest struct {
 	UserID   string `json:"user_id" binding:"required"`
 	IsActive bool   `json:"is_active"`
-}
\ No newline at end of file
+}
+type AddTeamMemberRequest struct {
+	TeamName  string `json:"team_name" binding:"required"`
+	UserID    string `json:"user_id" binding:"required"`
+	Username  string `json:"username" binding:"required"`
+	IsActive  bool   `json:"is_active"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type RemoveTeamMemberRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+	UserID   string `json:"user_id" binding:"required"`
+}
+
+type MoveTeamMemberRequest struct {
+	UserID       string `json:"user_id" binding:"required"`
+	FromTeamName string `json:"from_team_name"`
+	ToTeamName   string `json:"to_team_name" binding:"required"`
+}
+
+type SetMemberIsActiveRequest struct {
+	TeamName string `json:"team_name" binding:"required"`
+	UserID   string `json:"user_id" binding:"required"`
+	IsActive bool   `json:"is_active"`
+}
+
+type SetPrimaryTeamRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	TeamName string `json:"team_name" binding:"required"`
+}
+
+type UpdateTeamRequest struct {
+	TeamName       string  `json:"team_name" binding:"required"`
+	NewTeamName    string  `json:"new_team_name"`
+	ParentTeamName *string `json:"parent_team_name"`
+	MaxOpenReviews *int    `json:"max_open_reviews"`
+	ReviewSLAHours *int    `json:"review_sla_hours"`
+	StalePolicy    *string `json:"stale_policy" binding:"omitempty,oneof=NUDGE ADD_REVIEWER REASSIGN"`
+}
+
+// TeamUpdate — изменения команды для TeamRepository.Update; nil-поле не
+// меняется. Флаги Set* отличают сброс в NULL от отсутствия изменения.
+type TeamUpdate struct {
+	TeamName          *string
+	SetParent         bool
+	ParentTeamID      *string
+	SetMaxOpenReviews bool
+	MaxOpenReviews    *int
+	SetReviewSLA      bool
+	ReviewSLAHours    *int
+	StalePolicy       *string
+}
+
+type SetIsArchivedRequest struct {
+	TeamName   string `json:"team_name" binding:"required"`
+	IsArchived bool   `json:"is_archived"`
+}
+
+type UpdateUserRequest struct {
+	UserID     string     `json:"user_id" binding:"required"`
+	Username   *string    `json:"username"`
+	Attributes Attributes `json:"attributes"`
+}
+
+type ListUsersQuery struct {
+	TeamName string `form:"team_name"`
+	IsActive *bool  `form:"is_active"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset   int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type UserFilter struct {
+	TeamID   string
+	IsActive *bool
+	Limit    int
+	Offset   int
+}
+
+type UserList struct {
+	Users  []User `json:"users"`
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+}
+
+// AuditEvent — запись журнала аудита. Actor — проверенный инициатор
+// (пользователь или токен), ClaimedActor — непроверенный X-Actor-ID клиента.
+type AuditEvent struct {
+	ID           string          `db:"id" json:"id"`
+	Actor        string          `db:"actor" json:"actor,omitempty"`
+	ClaimedActor string          `db:"claimed_actor" json:"claimed_actor,omitempty"`
+	Action       string          `db:"action" json:"action"`
+	EntityType   string          `db:"entity_type" json:"entity_type"`
+	EntityID     string          `db:"entity_id" json:"entity_id"`
+	Before       json.RawMessage `db:"before_state" json:"before"`
+	After        json.RawMessage `db:"after_state" json:"after"`
+	RequestID    string          `db:"request_id" json:"request_id,omitempty"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+}
+
+type ListAuditQuery struct {
+	Actor        string    `form:"actor"`
+	ClaimedActor string    `form:"claimed_actor"`
+	Action       string    `form:"action"`
+	EntityType   string    `form:"entity_type"`
+	EntityID     string    `form:"entity_id"`
+	From         time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To           time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit        int       `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset       int       `form:"offset" binding:"omitempty,min=0"`
+}
+
+type AuditFilter struct {
+	Actor        string
+	ClaimedActor string
+	Action       string
+	EntityType   string
+	EntityID     string
+	From         time.Time
+	To           time.Time
+	Limit        int
+	Offset       int
+}
+
+type AuditEventList struct {
+	Events []AuditEvent `json:"events"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+type TurnaroundRow struct {
+	Key           string          `db:"key"`
+	DecisionCount int             `db:"decision_count"`
+	DecisionP50   sql.NullFloat64 `db:"decision_p50"`
+	DecisionP90   sql.NullFloat64 `db:"decision_p90"`
+	ApprovalCount int             `db:"approval_count"`
+	ApprovalP50   sql.NullFloat64 `db:"approval_p50"`
+	ApprovalP90   sql.NullFloat64 `db:"approval_p90"`
+	MergeCount    int             `db:"merge_count"`
+	MergeP50      sql.NullFloat64 `db:"merge_p50"`
+	MergeP90      sql.NullFloat64 `db:"merge_p90"`
+}
+
+type StatsQuery struct {
+	Type     string `form:"type"`
+	TeamName string `form:"team_name"`
+	Status   string `form:"status" binding:"omitempty,oneof=OPEN MERGED CLOSED"`
+	GroupBy  string `form:"group_by" binding:"omitempty,oneof=day week"`
+	// OutlierStddev — порог выброса в отчёте о равномерности, в стандартных отклонениях.
+	OutlierStddev float64 `form:"outlier_stddev" binding:"omitempty,gt=0"`
+	Limit         int     `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset        int     `form:"offset" binding:"omitempty,min=0"`
+}
+
+type StatsFilter struct {
+	From    time.Time
+	To      time.Time
+	TeamID  string
+	Status  string
+	GroupBy string
+	Limit   int
+	Offset  int
+}
+
+type UserAssignmentStat struct {
+	Bucket          *time.Time `db:"bucket" json:"bucket,omitempty"`
+	UserID          string     `db:"user_id" json:"user_id"`
+	AssignmentCount int        `db:"assignment_count" json:"assignment_count"`
+}
+
+type UserAssignmentStatsList struct {
+	Stats  []UserAssignmentStat `json:"stats"`
+	Total  int                  `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+type PRReviewerStat struct {
+	Bucket        *time.Time `db:"bucket" json:"bucket,omitempty"`
+	PRID          string     `db:"pr_id" json:"pr_id"`
+	ReviewerCount int        `db:"reviewer_count" json:"reviewer_count"`
+}
+
+type PRReviewerStatsList struct {
+	Stats  []PRReviewerStat `json:"stats"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+}
+
+type FairnessRow struct {
+	TeamName        string  `db:"team_name"`
+	UserID          string  `db:"user_id"`
+	AssignmentCount int     `db:"assignment_count"`
+	ActiveSeconds   float64 `db:"active_seconds"`
+}
+
+type FairnessMember struct {
+	UserID          string  `json:"user_id"`
+	AssignmentCount int     `json:"assignment_count"`
+	ActiveRatio     float64 `json:"active_ratio"`
+	NormalizedCount float64 `json:"normalized_count"`
+	IsOutlier       bool    `json:"is_outlier"`
+}
+
+type FairnessTeam struct {
+	TeamName    string           `json:"team_name"`
+	Members     []FairnessMember `json:"members"`
+	Min         float64          `json:"min"`
+	Max         float64          `json:"max"`
+	Mean        float64          `json:"mean"`
+	Stddev      float64          `json:"stddev"`
+	Gini        float64          `json:"gini"`
+	MaxMinRatio *float64         `json:"max_min_ratio"`
+}
+
+type FairnessReport struct {
+	From          time.Time      `json:"from"`
+	To            time.Time      `json:"to"`
+	OutlierStddev float64        `json:"outlier_stddev"`
+	Teams         []FairnessTeam `json:"teams"`
+}
+
+type OpenReviewLoad struct {
+	TeamName    string `db:"team_name"`
+	UserID      string `db:"user_id"`
+	OpenReviews int    `db:"open_reviews"`
+}
+
+type DurationStats struct {
+	Count      int      `json:"count"`
+	P50Seconds *float64 `json:"p50_seconds"`
+	P90Seconds *float64 `json:"p90_seconds"`
+}
+
+type TurnaroundEntry struct {
+	UserID               string        `json:"user_id,omitempty"`
+	TeamName             string        `json:"team_name,omitempty"`
+	TimeToFirstDecision  DurationStats `json:"time_to_first_decision"`
+	AssignmentToApproval DurationStats `json:"assignment_to_approval"`
+	OpenToMerge          DurationStats `json:"open_to_merge"`
+}
+
+type TurnaroundStats struct {
+	From  time.Time         `json:"from"`
+	To    time.Time         `json:"to"`
+	Users []TurnaroundEntry `json:"users"`
+	Teams []TurnaroundEntry `json:"teams"`
+}
+
+// IdempotencyRecord — сохранённый результат запроса с заголовком
+// Idempotency-Key. StatusCode пуст, пока первый запрос не завершился.
+// Headers — заголовки HTTP-ответа, хранятся в JSONB.
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (h *Headers) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return fmt.Errorf("cannot scan %T into Headers", src)
+	}
+}
+
+type IdempotencyRecord struct {
+	Owner           string    `db:"owner"`
+	Key             string    `db:"idempotency_key"`
+	Route           string    `db:"route"`
+	RequestHash     string    `db:"request_hash"`
+	StatusCode      *int      `db:"status_code"`
+	ResponseBody    []byte    `db:"response_body"`
+	ResponseHeaders Headers   `db:"response_headers"`
+	CreatedAt       time.Time `db:"created_at"`
+	ExpiresAt       time.Time `db:"expires_at"`
+}
+
+// Области доступа API-токенов. ScopeAdmin разрешает всё.
+const (
+	ScopeTeamsRead  = "teams:read"
+	ScopeTeamsWrite = "teams:write"
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopePRsRead    = "prs:read"
+	ScopePRsWrite   = "prs:write"
+	ScopeStatsRead  = "stats:read"
+	ScopeAuditRead  = "audit:read"
+	ScopeAdmin      = "admin"
+)
+
+// APIToken — выпущенный токен доступа. Сам токен не хранится, только его хеш.
+type APIToken struct {
+	ID         string         `db:"id" json:"token_id"`
+	Name       string         `db:"name" json:"name"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	UserID     *string        `db:"user_id" json:"user_id,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// IssuedAPIToken возвращается один раз при выпуске и содержит сам токен.
+type IssuedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+type IssueTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=teams:read teams:write users:read users:write prs:read prs:write stats:read audit:read admin"`
+	UserID    string     `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RevokeTokenRequest struct {
+	TokenID string `json:"token_id" binding:"required,uuid"`
+}
+
+// Principal — аутентифицированный владелец токена текущего запроса.
+// UserID задан, если токен выпущен от имени пользователя.
+type Principal struct {
+	TokenID string
+	Name    string
+	Scopes  []string
+	UserID  string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Роли пользователей. ORG_ADMIN и BOT действуют во всей организации,
+// TEAM_LEAD и MEMBER — в своей команде.
+const (
+	RoleOrgAdmin = "ORG_ADMIN"
+	RoleTeamLead = "TEAM_LEAD"
+	RoleMember   = "MEMBER"
+	RoleBot      = "BOT"
+)
+
+type UserRole struct {
+	Role      string    `db:"role" json:"role"`
+	TeamName  *string   `db:"team_name" json:"team_name,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type UserRoles struct {
+	UserID string     `json:"user_id"`
+	Roles  []UserRole `json:"roles"`
+}
+
+type RoleRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=ORG_ADMIN TEAM_LEAD MEMBER BOT"`
+	TeamName string `json:"team_name"`
+}
+
+// Внешние системы разработки, события которых принимает сервис.
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+)
+
+// ForgeUserMapping связывает логин во внешней системе с пользователем сервиса.
+// ExternalID — числовой id пользователя во внешней системе: GitLab называет
+// автора MR только по нему.
+type ForgeUserMapping struct {
+	Forge      string    `db:"forge" json:"forge"`
+	Login      string    `db:"login" json:"login"`
+	ExternalID *int64    `db:"external_id" json:"external_id,omitempty"`
+	UserID     string    `db:"user_id" json:"user_id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type MapForgeUserRequest struct {
+	Forge      string `json:"forge" binding:"required,oneof=github gitlab"`
+	Login      string `json:"login" binding:"required,max=255"`
+	ExternalID *int64 `json:"external_id" binding:"omitempty,min=1"`
+	UserID     string `json:"user_id" binding:"required"`
+}
+
+type UnmapForgeUserRequest struct {
+	Forge string `json:"forge" binding:"required,oneof=github gitlab"`
+	Login string `json:"login" binding:"required"`
+}
+
+// WebhookDelivery — входящий запрос webhook'а с необработанным телом.
+// Signature — подпись тела (GitHub) или секретный токен (GitLab).
+type WebhookDelivery struct {
+	Event      string
+	DeliveryID string
+	Signature  string
+	Body       []byte
+}
+
+// Результаты обработки события webhook'а.
+const (
+	WebhookStatusProcessed = "processed"
+	WebhookStatusIgnored   = "ignored"
+)
+
+type WebhookResult struct {
+	Status        string `json:"status"`
+	Action        string `json:"action,omitempty"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Типы событий, которые рассылаются подписчикам исходящих webhook'ов.
+const (
+	EventPRCreated          = "pr.created"
+	EventReviewerAssigned   = "reviewer.assigned"
+	EventReviewerReassigned = "reviewer.reassigned"
+	EventPRMerged           = "pr.merged"
+	EventUserDeactivated    = "user.deactivated"
+)
+
+// WebhookSubscription — подписчик исходящих событий; пустой Events —
+// подписка на все события.
+type WebhookSubscription struct {
+	ID        string         `db:"id" json:"subscription_id"`
+	URL       string         `db:"url" json:"url"`
+	Secret    string         `db:"secret" json:"-"`
+	Events    pq.StringArray `db:"events" json:"events"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+type CreateWebhookSubscriptionRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Secret string   `json:"secret" binding:"required,min=16,max=255"`
+	Events []string `json:"events" binding:"omitempty,dive,oneof=pr.created reviewer.assigned reviewer.reassigned pr.merged user.deactivated"`
+}
+
+type DeleteWebhookSubscriptionQuery struct {
+	SubscriptionID string `form:"subscription_id" binding:"required,uuid"`
+}
+
+// WebhookEvent — тело запроса к подписчику.
+type WebhookEvent struct {
+	ID         string          `db:"event_id" json:"id"`
+	Type       string          `db:"event_type" json:"type"`
+	Actor      string          `db:"actor" json:"actor,omitempty"`
+	Data       json.RawMessage `db:"data" json:"data"`
+	OccurredAt time.Time       `db:"occurred_at" json:"occurred_at"`
+}
+
+// Данные событий в поле data.
+type PullRequestEventData struct {
+	PullRequest *PullRequest `json:"pull_request"`
+}
+
+type ReviewerEventData struct {
+	PullRequestID   string `json:"pull_request_id"`
+	PullRequestName string `json:"pull_request_name"`
+	AuthorID        string `json:"author_id"`
+	ReviewerID      string `json:"reviewer_id"`
+	OldReviewerID   string `json:"old_reviewer_id,omitempty"`
+	Reason          string `json:"reason"`
+}
+
+type UserEventData struct {
+	User *User `json:"user"`
+}
+
+// Состояния доставки события подписчику: DEAD — попытки исчерпаны.
+const (
+	EventDeliveryPending   = "PENDING"
+	EventDeliveryDelivered = "DELIVERED"
+	EventDeliveryDead      = "DEAD"
+)
+
+type EventDelivery struct {
+	ID             string     `db:"id" json:"delivery_id"`
+	SubscriptionID string     `db:"subscription_id" json:"subscription_id"`
+	EventID        string     `db:"event_id" json:"event_id"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `db:"last_attempt_at" json:"last_attempt_at,omitempty"`
+	ResponseStatus *int       `db:"response_status" json:"response_status,omitempty"`
+	LastError      string     `db:"last_error" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ClaimedEventDelivery — доставка, взятая воркером, вместе с событием и
+// адресом подписчика.
+type ClaimedEventDelivery struct {
+	WebhookEvent
+	DeliveryID string `db:"id"`
+	Attempts   int    `db:"attempts"`
+	URL        string `db:"url"`
+	Secret     string `db:"secret"`
+}
+
+type ListEventDeliveriesQuery struct {
+	SubscriptionID string `form:"subscription_id" binding:"omitempty,uuid"`
+	EventType      string `form:"event_type"`
+	Status         string `form:"status" binding:"omitempty,oneof=PENDING DELIVERED DEAD"`
+	Limit          int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Offset         int    `form:"offset" binding:"omitempty,min=0"`
+}
+
+type EventDeliveryFilter struct {
+	SubscriptionID string
+	EventType      string
+	Status         string
+	Limit          int
+	Offset         int
+}
+
+type EventDeliveryList struct {
+	Deliveries []EventDelivery `json:"deliveries"`
+	Total      int             `json:"total"`
+	Limit      int             `json:"limit"`
+	Offset     int             `json:"offset"`
+}
+
+type RedeliverEventRequest struct {
+	DeliveryID string `json:"delivery_id" binding:"required,uuid"`
+}
+
+// EventDeliveryResult — итог одного прохода воркера доставки.
+type EventDeliveryResult struct {
+	Delivered int `json:"delivered"`
+	Retried   int `json:"retried"`
+	Dead      int `json:"dead"`
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Заголовки запроса к подписчику исходящих событий.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature-256"
+)
+
+// EventSender доставляет подписанные события подписчикам webhook'ов.
+type EventSender interface {
+	// Send возвращает код ответа подписчика; ответ не 2xx — ошибка.
+	Send(ctx context.Context, url, secret, eventType, deliveryID string, body []byte) (int, error)
+}
+
+type httpEventSender struct {
+	client *http.Client
+}
+
+// NewEventSender отправляет события POST-запросом с JSON; подпись в
+// X-Webhook-Signature-256 — "sha256=<hex HMAC-SHA256 тела>" с секретом подписки.
+func NewEventSender(timeout time.Duration) EventSender {
+	return &httpEventSender{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *httpEventSender) Send(ctx context.Context, url, secret, eventType, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderSignature, Sign(secret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Дочитываем ответ, чтобы соединение вернулось в пул.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает значение заголовка X-Webhook-Signature-256 для тела.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+// Notifier доставляет напоминания ревьюверам о просроченных ревью.
+type Notifier interface {
+	NotifyStaleReview(ctx context.Context, review model.StaleReview) error
+}
+
+type logNotifier struct {
+	logger *zap.Logger
+}
+
+// NewLogNotifier пишет напоминания в лог; используется, когда внешний хук не настроен.
+func NewLogNotifier(logger *zap.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) NotifyStaleReview(ctx context.Context, review model.StaleReview) error {
+	n.logger.Info("Stale review reminder",
+		zap.String("pr_id", review.PullRequestID),
+		zap.String("reviewer", review.ReviewerUserID),
+		zap.Time("assigned_at", review.AssignedAt),
+		zap.Int("sla_hours", review.SLAHours),
+	)
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier отправляет напоминание POST-запросом с JSON на url.
+func NewWebhookNotifier(url string, timeout time.Duration) Notifier {
+	return &webhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *webhookNotifier) NotifyStaleReview(ctx context.Context, review model.StaleReview) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":  "review.stale",
+		"review": review,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("notification hook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
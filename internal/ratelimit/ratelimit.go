@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit — не больше Requests запросов за Period. Bucket вмещает Requests
+// токенов и равномерно пополняется за Period, поэтому после простоя
+// допустим всплеск до Requests запросов.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // когда появится токен, если запрос отклонён
+	Reset      time.Duration // когда bucket наполнится полностью
+}
+
+// Limiter расходует токен из bucket по ключу.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	// Cleanup удаляет bucket'ы, не использованные дольше idle.
+	Cleanup(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+func newResult(tokens float64, allowed bool, limit Limit) Result {
+	rate := limit.rate()
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type memoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryLimiter хранит bucket'ы в памяти процесса: лимиты действуют
+// отдельно на каждой реплике.
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Requests), b.tokens+now.Sub(b.updated).Seconds()*limit.rate())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(b.tokens, allowed, limit), nil
+}
+
+func (l *memoryLimiter) Cleanup(ctx context.Context, idle time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var deleted int64
+	threshold := l.now().Add(-idle)
+	for key, b := range l.buckets {
+		if b.updated.Before(threshold) {
+			delete(l.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Store — общее хранилище bucket'ов. Take атомарно пополняет bucket и
+// забирает токен, если он есть.
+type Store interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error)
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+type storeLimiter struct {
+	store Store
+}
+
+// NewStoreLimiter хранит bucket'ы в общем хранилище (Postgres), чтобы
+// лимиты соблюдались на всех репликах вместе.
+func NewStoreLimiter(store Store) Limiter {
+	return &storeLimiter{store: store}
+}
+
+func (l *storeLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	tokens, allowed, err := l.store.Take(ctx, key, limit.rate(), limit.Requests)
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(tokens, allowed, limit), nil
+}
+
+func (l *storeLimiter) Cleanup(ctx context.Context, idle time.Duration) (int64, error) {
+	return l.store.DeleteIdle(ctx, idle)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_TokenBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewMemoryLimiter().(*memoryLimiter)
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Requests: 3, Period: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, _ := limiter.Allow(ctx, "client", limit)
+		if !result.Allowed || result.Remaining != i {
+			t.Fatalf("Expected allowed request with %d remaining, got %+v", i, result)
+		}
+	}
+
+	result, _ := limiter.Allow(ctx, "client", limit)
+	if result.Allowed {
+		t.Fatal("Expected request over the limit to be rejected")
+	}
+	if result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("Unexpected retry hints: %+v", result)
+	}
+
+	// Другой клиент не зависит от первого
+	if result, _ := limiter.Allow(ctx, "other", limit); !result.Allowed {
+		t.Error("Expected another client to be allowed")
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := limiter.Allow(ctx, "client", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Expected one token to be refilled, got %+v", result)
+	}
+
+	// Простой дольше периода не копит токены сверх лимита
+	now = now.Add(time.Hour)
+	if result, _ := limiter.Allow(ctx, "client", limit); result.Remaining != 2 {
+		t.Errorf("Expected bucket capped at limit, got %+v", result)
+	}
+}
+
+func TestMemoryLimiter_Cleanup(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewMemoryLimiter().(*memoryLimiter)
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Requests: 1, Period: time.Minute}
+
+	limiter.Allow(ctx, "old", limit)
+	now = now.Add(2 * time.Minute)
+	limiter.Allow(ctx, "fresh", limit)
+
+	deleted, _ := limiter.Cleanup(ctx, time.Minute)
+	if deleted != 1 {
+		t.Errorf("Expected 1 idle bucket deleted, got %d", deleted)
+	}
+	if _, ok := limiter.buckets["fresh"]; !ok {
+		t.Error("Expected recent bucket to be kept")
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) error
+	List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, int, error)
+}
+
+type auditRepository struct {
+	db *tracedDB
+}
+
+func NewAuditRepository(db *sqlx.DB, queryTimeout time.Duration) AuditRepository {
+	return &auditRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+func (r *auditRepository) Create(ctx context.Context, event *model.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (actor, claimed_actor, action, entity_type, entity_id, before_state, after_state, request_id)
+		VALUES (NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5, $6::jsonb, $7::jsonb, NULLIF($8, ''))
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		event.Actor,
+		event.ClaimedActor,
+		event.Action,
+		event.EntityType,
+		event.EntityID,
+		jsonArg(event.Before),
+		jsonArg(event.After),
+		event.RequestID,
+	)
+	return err
+}
+
+func (r *auditRepository) List(ctx context.Context, filter model.AuditFilter) ([]model.AuditEvent, int, error) {
+	where := ` WHERE TRUE`
+	args := []interface{}{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(condition, len(args))
+	}
+
+	if filter.Actor != "" {
+		addCondition(` AND actor = $%d`, filter.Actor)
+	}
+	if filter.ClaimedActor != "" {
+		addCondition(` AND claimed_actor = $%d`, filter.ClaimedActor)
+	}
+	if filter.Action != "" {
+		addCondition(` AND action = $%d`, filter.Action)
+	}
+	if filter.EntityType != "" {
+		addCondition(` AND entity_type = $%d`, filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		addCondition(` AND entity_id = $%d`, filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		addCondition(` AND created_at >= $%d`, filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition(` AND created_at < $%d`, filter.To)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM audit_events`+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `
+		SELECT id, COALESCE(actor, '') AS actor, COALESCE(claimed_actor, '') AS claimed_actor, action, entity_type, entity_id,
+		       COALESCE(before_state, 'null'::jsonb) AS before_state,
+		       COALESCE(after_state, 'null'::jsonb) AS after_state,
+		       COALESCE(request_id, '') AS request_id, created_at
+		FROM audit_events` + where + fmt.Sprintf(`
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	var events []model.AuditEvent
+	if err := r.db.SelectContext(ctx, &events, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	if events == nil {
+		events = []model.AuditEvent{}
+	}
+
+	return events, total, nil
+}
+
+// jsonArg передаёт JSON как текст: []byte драйвер отправил бы как bytea.
+func jsonArg(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// tracedDB выполняет запросы с контекстом вызова и открывает на каждый
+// запрос отдельный спан, чтобы было видно, какой из них медленный.
+// Если задан queryTimeout, каждый запрос ограничен этим временем.
+type tracedDB struct {
+	db           *sqlx.DB
+	queryTimeout time.Duration
+}
+
+func newTracedDB(db *sqlx.DB, queryTimeout time.Duration) *tracedDB {
+	return &tracedDB{db: db, queryTimeout: queryTimeout}
+}
+
+func (d *tracedDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withQueryTimeout(ctx, d.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	err := queryError(ctx, d.db.GetContext(ctx, dest, query, args...))
+	endQuerySpan(span, err)
+	return err
+}
+
+func (d *tracedDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withQueryTimeout(ctx, d.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	err := queryError(ctx, d.db.SelectContext(ctx, dest, query, args...))
+	endQuerySpan(span, err)
+	return err
+}
+
+func (d *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withQueryTimeout(ctx, d.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := d.db.ExecContext(ctx, query, args...)
+	err = queryError(ctx, err)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+// QueryxContext — спан покрывает выполнение запроса до первой строки,
+// чтение курсора в него не входит. Таймаут запроса здесь не применяется:
+// курсор выгрузки читается долго и ограничен только контекстом вызова.
+func (d *tracedDB) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := d.db.QueryxContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (d *tracedDB) BeginTxx(ctx context.Context) (*tracedTx, error) {
+	ctx, span := tracing.StartWithKind(ctx, tracing.KindClient, "db.transaction",
+		tracing.String("db.system", "postgresql"))
+
+	tx, err := d.db.BeginTxx(ctx, nil)
+	if err != nil {
+		endQuerySpan(span, err)
+		return nil, err
+	}
+	return &tracedTx{tx: tx, span: span, queryTimeout: d.queryTimeout}, nil
+}
+
+// tracedTx — транзакция, запросы которой попадают в спан транзакции.
+// Таймаут действует на каждый запрос внутри неё; вся транзакция
+// ограничена контекстом, переданным в BeginTxx.
+type tracedTx struct {
+	tx           *sqlx.Tx
+	span         *tracing.Span
+	queryTimeout time.Duration
+	done         bool
+}
+
+func (t *tracedTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withQueryTimeout(t.context(ctx), t.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	err := queryError(ctx, t.tx.GetContext(ctx, dest, query, args...))
+	endQuerySpan(span, err)
+	return err
+}
+
+func (t *tracedTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withQueryTimeout(t.context(ctx), t.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	err := queryError(ctx, t.tx.SelectContext(ctx, dest, query, args...))
+	endQuerySpan(span, err)
+	return err
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withQueryTimeout(t.context(ctx), t.queryTimeout)
+	defer cancel()
+
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	err = queryError(ctx, err)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (t *tracedTx) Commit() error {
+	err := t.tx.Commit()
+	t.finish(err)
+	return err
+}
+
+// Rollback после Commit ничего не делает, поэтому его можно вызывать в defer.
+func (t *tracedTx) Rollback() error {
+	err := t.tx.Rollback()
+	if err == sql.ErrTxDone {
+		return err
+	}
+	t.span.SetAttributes(tracing.Bool("db.rollback", true))
+	t.finish(err)
+	return err
+}
+
+func (t *tracedTx) context(ctx context.Context) context.Context {
+	if t.span == nil {
+		return ctx
+	}
+	return tracing.ContextWithSpanContext(ctx, t.span.SpanContext())
+}
+
+func (t *tracedTx) finish(err error) {
+	if t.done {
+		return
+	}
+	t.done = true
+	endQuerySpan(t.span, err)
+}
+
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// queryError заменяет ошибку драйвера ошибкой контекста, если запрос
+// прерван по таймауту или отмене: драйвер сообщает об этом по-разному.
+func queryError(ctx context.Context, err error) error {
+	if err != nil && err != sql.ErrNoRows && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, *tracing.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation := statement
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		operation = statement[:i]
+	}
+
+	return tracing.StartWithKind(ctx, tracing.KindClient, "db."+strings.ToLower(operation),
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.statement", statement),
+	)
+}
+
+func endQuerySpan(span *tracing.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+	}
+	span.End()
+}
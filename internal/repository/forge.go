@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type ForgeUserRepository interface {
+	Upsert(ctx context.Context, forge, login string, externalID *int64, userInternalID string) error
+	Delete(ctx context.Context, forge, login string) (bool, error)
+	Get(ctx context.Context, forge, login string) (*model.ForgeUserMapping, error)
+	GetByExternalID(ctx context.Context, forge string, externalID int64) (*model.ForgeUserMapping, error)
+	List(ctx context.Context, forge string) ([]model.ForgeUserMapping, error)
+}
+
+type forgeUserRepository struct {
+	db *tracedDB
+}
+
+func NewForgeUserRepository(db *sqlx.DB, queryTimeout time.Duration) ForgeUserRepository {
+	return &forgeUserRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+const forgeUserSelect = `
+	SELECT m.forge, m.login, m.external_id, u.user_id, m.created_at
+	FROM forge_user_mappings m
+	JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+`
+
+// Upsert снимает числовой id с прежнего логина: так переименованный во
+// внешней системе пользователь не оставляет устаревшее сопоставление.
+func (r *forgeUserRepository) Upsert(ctx context.Context, forge, login string, externalID *int64, userInternalID string) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if externalID != nil {
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM forge_user_mappings WHERE forge = $1 AND external_id = $2 AND login <> $3`,
+			forge, *externalID, login,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO forge_user_mappings (forge, login, external_id, user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (forge, login) DO UPDATE
+		SET external_id = EXCLUDED.external_id, user_id = EXCLUDED.user_id, created_at = NOW()
+	`
+	if _, err := tx.ExecContext(ctx, query, forge, login, externalID, userInternalID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *forgeUserRepository) Delete(ctx context.Context, forge, login string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM forge_user_mappings WHERE forge = $1 AND login = $2`, forge, login)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Get не находит сопоставления удалённых пользователей.
+func (r *forgeUserRepository) Get(ctx context.Context, forge, login string) (*model.ForgeUserMapping, error) {
+	var mapping model.ForgeUserMapping
+	query := forgeUserSelect + `WHERE m.forge = $1 AND m.login = $2`
+	if err := r.db.GetContext(ctx, &mapping, query, forge, login); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (r *forgeUserRepository) GetByExternalID(ctx context.Context, forge string, externalID int64) (*model.ForgeUserMapping, error) {
+	var mapping model.ForgeUserMapping
+	query := forgeUserSelect + `WHERE m.forge = $1 AND m.external_id = $2`
+	if err := r.db.GetContext(ctx, &mapping, query, forge, externalID); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+func (r *forgeUserRepository) List(ctx context.Context, forge string) ([]model.ForgeUserMapping, error) {
+	mappings := []model.ForgeUserMapping{}
+	query := forgeUserSelect + `WHERE ($1 = '' OR m.forge = $1) ORDER BY m.forge, m.login`
+	if err := r.db.SelectContext(ctx, &mappings, query, forge); err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
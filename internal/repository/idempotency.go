@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, owner, key, route, requestHash string, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, owner, key, route string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, owner, key, route string, statusCode int, headers model.Headers, body []byte) error
+	Delete(ctx context.Context, owner, key, route string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *tracedDB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB, queryTimeout time.Duration) IdempotencyRepository {
+	return &idempotencyRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+// Reserve занимает ключ владельца owner за текущим запросом. Просроченная
+// запись перезаписывается; если ключ занят живой записью, возвращается false.
+func (r *idempotencyRepository) Reserve(ctx context.Context, owner, key, route, requestHash string, ttl time.Duration) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (owner, idempotency_key, route, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + $5 * INTERVAL '1 second')
+		ON CONFLICT (owner, idempotency_key, route) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    response_body = NULL,
+		    response_headers = NULL,
+		    created_at = NOW(),
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING TRUE
+	`
+	var reserved bool
+	err := r.db.GetContext(ctx, &reserved, query, owner, key, route, requestHash, ttl.Seconds())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return reserved, err
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, owner, key, route string) (*model.IdempotencyRecord, error) {
+	query := `
+		SELECT owner, idempotency_key, route, request_hash, status_code, response_body, response_headers, created_at, expires_at
+		FROM idempotency_keys
+		WHERE owner = $1 AND idempotency_key = $2 AND route = $3
+	`
+	var record model.IdempotencyRecord
+	if err := r.db.GetContext(ctx, &record, query, owner, key, route); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, owner, key, route string, statusCode int, headers model.Headers, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $4, response_headers = $5, response_body = $6
+		WHERE owner = $1 AND idempotency_key = $2 AND route = $3
+	`
+	_, err := r.db.ExecContext(ctx, query, owner, key, route, statusCode, headers, body)
+	return err
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, owner, key, route string) error {
+	query := `DELETE FROM idempotency_keys WHERE owner = $1 AND idempotency_key = $2 AND route = $3`
+	_, err := r.db.ExecContext(ctx, query, owner, key, route)
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LockRepository interface {
+	TryWithLock(ctx context.Context, key int64, fn func() error) (bool, error)
+}
+
+type lockRepository struct {
+	db *tracedDB
+}
+
+func NewLockRepository(db *sqlx.DB, queryTimeout time.Duration) LockRepository {
+	return &lockRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+// TryWithLock выполняет fn под транзакционной advisory-блокировкой Postgres.
+// Если блокировку держит другой экземпляр сервиса, fn не вызывается и возвращается false.
+func (r *lockRepository) TryWithLock(ctx context.Context, key int64, fn func() error) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var acquired bool
+	if err := tx.GetContext(ctx, &acquired, `SELECT pg_try_advisory_xact_lock($1)`, key); err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	if err := fn(); err != nil {
+		return true, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type MembershipRepository interface {
+	Add(ctx context.Context, userInternalID, teamID string, isPrimary, isActive bool) error
+	Remove(ctx context.Context, userInternalID, teamID string) error
+	Move(ctx context.Context, userInternalID, fromTeamID, toTeamID string) error
+	Get(ctx context.Context, userInternalID, teamID string) (*model.TeamMembership, error)
+	GetByUserID(ctx context.Context, userInternalID string) ([]model.TeamMembership, error)
+	SetPrimary(ctx context.Context, userInternalID, teamID string) error
+	SetIsActive(ctx context.Context, userInternalID, teamID string, isActive bool) error
+}
+
+type membershipRepository struct {
+	db *tracedDB
+}
+
+func NewMembershipRepository(db *sqlx.DB, queryTimeout time.Duration) MembershipRepository {
+	return &membershipRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+func (r *membershipRepository) Add(ctx context.Context, userInternalID, teamID string, isPrimary, isActive bool) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if isPrimary {
+		query := `
+			UPDATE team_memberships
+			SET is_primary = false, updated_at = NOW()
+			WHERE user_id = $1 AND is_primary
+		`
+		if _, err := tx.ExecContext(ctx, query, userInternalID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO team_memberships (user_id, team_id, is_primary, is_active)
+		VALUES ($1, $2, $3 OR NOT EXISTS (
+			SELECT 1 FROM team_memberships WHERE user_id = $1 AND is_primary
+		), $4)
+	`
+	if _, err := tx.ExecContext(ctx, query, userInternalID, teamID, isPrimary, isActive); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *membershipRepository) Remove(ctx context.Context, userInternalID, teamID string) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasPrimary bool
+	query := `
+		DELETE FROM team_memberships
+		WHERE user_id = $1 AND team_id = $2
+		RETURNING is_primary
+	`
+	if err := tx.GetContext(ctx, &wasPrimary, query, userInternalID, teamID); err != nil {
+		return err
+	}
+
+	if wasPrimary {
+		if err := promotePrimaryMemberships(ctx, tx, []string{userInternalID}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *membershipRepository) Move(ctx context.Context, userInternalID, fromTeamID, toTeamID string) error {
+	query := `
+		UPDATE team_memberships
+		SET team_id = $3, updated_at = NOW()
+		WHERE user_id = $1 AND team_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, userInternalID, fromTeamID, toTeamID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *membershipRepository) Get(ctx context.Context, userInternalID, teamID string) (*model.TeamMembership, error) {
+	query := `
+		SELECT m.team_id, t.team_name, t.is_archived AS team_archived, m.is_primary, m.is_active
+		FROM team_memberships m
+		JOIN teams t ON m.team_id = t.id
+		WHERE m.user_id = $1 AND m.team_id = $2
+	`
+	var membership model.TeamMembership
+	err := r.db.GetContext(ctx, &membership, query, userInternalID, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *membershipRepository) GetByUserID(ctx context.Context, userInternalID string) ([]model.TeamMembership, error) {
+	query := `
+		SELECT m.team_id, t.team_name, t.is_archived AS team_archived, m.is_primary, m.is_active
+		FROM team_memberships m
+		JOIN teams t ON m.team_id = t.id
+		WHERE m.user_id = $1
+		ORDER BY m.is_primary DESC, t.team_name
+	`
+	var memberships []model.TeamMembership
+	err := r.db.SelectContext(ctx, &memberships, query, userInternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if memberships == nil {
+		memberships = []model.TeamMembership{}
+	}
+
+	return memberships, nil
+}
+
+func (r *membershipRepository) SetPrimary(ctx context.Context, userInternalID, teamID string) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE team_memberships
+		SET is_primary = false, updated_at = NOW()
+		WHERE user_id = $1 AND is_primary AND team_id != $2
+	`
+	if _, err := tx.ExecContext(ctx, query, userInternalID, teamID); err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE team_memberships
+		SET is_primary = true, updated_at = NOW()
+		WHERE user_id = $1 AND team_id = $2
+	`
+	result, err := tx.ExecContext(ctx, query, userInternalID, teamID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *membershipRepository) SetIsActive(ctx context.Context, userInternalID, teamID string, isActive bool) error {
+	query := `
+		UPDATE team_memberships
+		SET is_active = $3, updated_at = NOW()
+		WHERE user_id = $1 AND team_id = $2
+	`
+	result, err := r.db.ExecContext(ctx, query, userInternalID, teamID, isActive)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// promotePrimaryMemberships делает основной самую раннюю из оставшихся команд
+// пользователей, у которых основной команды больше нет.
+func promotePrimaryMemberships(ctx context.Context, tx *tracedTx, userInternalIDs []string) error {
+	if len(userInternalIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE team_memberships m
+		SET is_primary = true, updated_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (user_id) user_id, team_id
+			FROM team_memberships
+			WHERE user_id = ANY($1)
+			  AND user_id NOT IN (SELECT user_id FROM team_memberships WHERE is_primary)
+			ORDER BY user_id, created_at
+		) candidate
+		WHERE m.user_id = candidate.user_id AND m.team_id = candidate.team_id
+	`
+	_, err := tx.ExecContext(ctx, query, pq.Array(userInternalIDs))
+	return err
+}
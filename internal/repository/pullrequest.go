@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"time"
 	"github.com/jmoiron/sqlx"
@@ -8,48 +9,59 @@ import (
 )
 
 type PullRequestRepository interface {
-	Create(prID, prName, authorID string) (string, error)
-	GetByPRID(prID string) (*model.PullRequest, error)
-	Exists(prID string) (bool, error)
-	UpdateStatus(id, status string, mergedAt *time.Time) error
+	Create(ctx context.Context, prID, prName, authorID, teamID string) (string, error)
+	GetByPRID(ctx context.Context, prID string) (*model.PullRequest, error)
+	Exists(ctx context.Context, prID string) (bool, error)
+	UpdateStatus(ctx context.Context, id, status string, mergedAt *time.Time, expectedVersion int) (int, error)
 	
-	AssignReviewer(prInternalID, userInternalID string) error
-	RemoveReviewer(prInternalID, userInternalID string) error
-	GetReviewerUserIDs(prInternalID string) ([]string, error)
-	GetPRsByReviewerUserID(userID string) ([]model.PullRequestShort, error)
-	IsReviewerAssigned(prInternalID, userInternalID string) (bool, error)
+	AssignReviewer(ctx context.Context, prInternalID, userInternalID, action string, expectedVersion int) (int, error)
+	RemoveReviewer(ctx context.Context, prInternalID, userInternalID, reason string, expectedVersion int) (int, error)
+	ReplaceReviewer(ctx context.Context, prInternalID, oldUserInternalID, newUserInternalID, action string, expectedVersion int) (int, error)
+	GetReviewerUserIDs(ctx context.Context, prInternalID string) ([]string, error)
+	GetPRsByReviewerUserID(ctx context.Context, userID string) ([]model.PullRequestShort, error)
+	StreamPRsByReviewerUserID(ctx context.Context, userID string, fn func(model.PullRequestShort) error) error
+	IsReviewerAssigned(ctx context.Context, prInternalID, userInternalID string) (bool, error)
 	
-	AssignReviewersBatch(prInternalID string, userInternalIDs []string) error
-	RemoveAllReviewers(prInternalID string) error
+	AssignReviewersBatch(ctx context.Context, prInternalID string, userInternalIDs []string, action string) error
+	RemoveAllReviewers(ctx context.Context, prInternalID, reason string) error
+	GetReviewerHistory(ctx context.Context, prInternalID string) ([]model.ReviewerAssignment, error)
 	
-	GetReviewerAssignmentCount(userInternalID string) (int, error)
+	GetReviewerAssignmentCount(ctx context.Context, userInternalID string) (int, error)
+
+	AddReviewDecision(ctx context.Context, prInternalID, userInternalID, decision string) (time.Time, error)
+
+	GetStaleReviews(ctx context.Context, defaultSLAHours, limit int) ([]model.StaleReview, error)
+	MarkReviewEscalated(ctx context.Context, prInternalID, userInternalID string) error
+	AssignEscalationReviewer(ctx context.Context, prInternalID, escalatedUserInternalID, userInternalID, action string, expectedVersion int) (int, error)
 }
 
 type pullRequestRepository struct {
-	db *sqlx.DB
+	db *tracedDB
 }
 
-func NewPullRequestRepository(db *sqlx.DB) PullRequestRepository {
-	return &pullRequestRepository{db: db}
+func NewPullRequestRepository(db *sqlx.DB, queryTimeout time.Duration) PullRequestRepository {
+	return &pullRequestRepository{db: newTracedDB(db, queryTimeout)}
 }
 
-func (r *pullRequestRepository) Create(prID, prName, authorID string) (string, error) {
+func (r *pullRequestRepository) Create(ctx context.Context, prID, prName, authorID, teamID string) (string, error) {
 	query := `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, 'OPEN', NOW())
+		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, team_id, status, created_at)
+		VALUES ($1, $2, $3, $4, 'OPEN', NOW())
 		RETURNING id
 	`
 	var id string
-	err := r.db.Get(&id, query, prID, prName, authorID)
+	err := r.db.GetContext(ctx, &id, query, prID, prName, authorID, teamID)
 	return id, err
 }
 
-func (r *pullRequestRepository) GetByPRID(prID string) (*model.PullRequest, error) {
+func (r *pullRequestRepository) GetByPRID(ctx context.Context, prID string) (*model.PullRequest, error) {
 	query := `
 		SELECT pr.id, pr.pull_request_id, pr.pull_request_name, 
-		       u.user_id as author_id, pr.status, pr.created_at, pr.merged_at
+		       u.user_id as author_id, COALESCE(pr.team_id::text, '') AS team_id,
+		       COALESCE(t.team_name, '') AS team_name, pr.status, pr.version, pr.created_at, pr.merged_at
 		FROM pull_requests pr
 		JOIN users u ON pr.author_id = u.id
+		LEFT JOIN teams t ON pr.team_id = t.id
 		WHERE pr.pull_request_id = $1
 	`
 	var prRow struct {
@@ -57,16 +69,19 @@ func (r *pullRequestRepository) GetByPRID(prID string) (*model.PullRequest, erro
 		PullRequestID     string       `db:"pull_request_id"`
 		PullRequestName   string       `db:"pull_request_name"`
 		AuthorID          string       `db:"author_id"`
+		TeamID            string       `db:"team_id"`
+		TeamName          string       `db:"team_name"`
 		Status            string       `db:"status"`
+		Version           int          `db:"version"`
 		CreatedAt         time.Time    `db:"created_at"`
 		MergedAt          sql.NullTime `db:"merged_at"`
 	}
-	err := r.db.Get(&prRow, query, prID)
+	err := r.db.GetContext(ctx, &prRow, query, prID)
 	if err != nil {
 		return nil, err
 	}
  
-	reviewers, err := r.GetReviewerUserIDs(prRow.ID)
+	reviewers, err := r.GetReviewerUserIDs(ctx, prRow.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +91,10 @@ func (r *pullRequestRepository) GetByPRID(prID string) (*model.PullRequest, erro
 		PullRequestID:     prRow.PullRequestID,
 		PullRequestName:   prRow.PullRequestName,
 		AuthorID:          prRow.AuthorID,
+		TeamID:            prRow.TeamID,
+		TeamName:          prRow.TeamName,
 		Status:            prRow.Status,
+		Version:           prRow.Version,
 		CreatedAt:         prRow.CreatedAt,
 		MergedAt:          prRow.MergedAt,
 		AssignedReviewers: reviewers,
@@ -85,52 +103,102 @@ func (r *pullRequestRepository) GetByPRID(prID string) (*model.PullRequest, erro
 	return pr, nil
 }
 
-func (r *pullRequestRepository) Exists(prID string) (bool, error) {
+func (r *pullRequestRepository) Exists(ctx context.Context, prID string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`
-	err := r.db.Get(&exists, query, prID)
+	err := r.db.GetContext(ctx, &exists, query, prID)
 	return exists, err
 }
 
-func (r *pullRequestRepository) UpdateStatus(id, status string, mergedAt *time.Time) error {
+// UpdateStatus меняет статус PR, если его версия равна expectedVersion,
+// и возвращает новую версию. При расхождении версий — sql.ErrNoRows.
+func (r *pullRequestRepository) UpdateStatus(ctx context.Context, id, status string, mergedAt *time.Time, expectedVersion int) (int, error) {
 	query := `
 		UPDATE pull_requests
-		SET status = $2, merged_at = $3
-		WHERE id = $1
+		SET status = $2, merged_at = $3, version = version + 1
+		WHERE id = $1 AND version = $4
+		RETURNING version
 	`
-	result, err := r.db.Exec(query, id, status, mergedAt)
+	var version int
+	err := r.db.GetContext(ctx, &version, query, id, status, mergedAt, expectedVersion)
+	return version, err
+}
+
+// AssignReviewer, RemoveReviewer и ReplaceReviewer меняют состав ревьюверов
+// и увеличивают версию PR в одной транзакции. Если expectedVersion не 0,
+// изменение выполняется только для открытого PR этой версии, иначе
+// возвращается sql.ErrNoRows.
+func (r *pullRequestRepository) AssignReviewer(ctx context.Context, prInternalID, userInternalID, action string, expectedVersion int) (int, error) {
+	return r.changeReviewers(ctx, prInternalID, expectedVersion, func(tx *tracedTx) error {
+		return insertReviewers(ctx, tx, prInternalID, []string{userInternalID}, action)
+	})
+}
+
+func (r *pullRequestRepository) RemoveReviewer(ctx context.Context, prInternalID, userInternalID, reason string, expectedVersion int) (int, error) {
+	return r.changeReviewers(ctx, prInternalID, expectedVersion, func(tx *tracedTx) error {
+		return deleteReviewer(ctx, tx, prInternalID, userInternalID, reason)
+	})
+}
+
+func (r *pullRequestRepository) ReplaceReviewer(ctx context.Context, prInternalID, oldUserInternalID, newUserInternalID, action string, expectedVersion int) (int, error) {
+	return r.changeReviewers(ctx, prInternalID, expectedVersion, func(tx *tracedTx) error {
+		if err := deleteReviewer(ctx, tx, prInternalID, oldUserInternalID, action); err != nil {
+			return err
+		}
+		return insertReviewers(ctx, tx, prInternalID, []string{newUserInternalID}, action)
+	})
+}
+
+// changeReviewers сначала увеличивает версию: UPDATE блокирует строку PR,
+// поэтому параллельные изменения одного PR выполняются по очереди, и
+// второе из них увидит уже новую версию.
+func (r *pullRequestRepository) changeReviewers(ctx context.Context, prInternalID string, expectedVersion int, change func(tx *tracedTx) error) (int, error) {
+	tx, err := r.db.BeginTxx(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	version, err := bumpVersion(ctx, tx, prInternalID, expectedVersion)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+
+	if err := change(tx); err != nil {
+		return 0, err
 	}
 
-	return nil
+	return version, tx.Commit()
 }
 
-func (r *pullRequestRepository) AssignReviewer(prInternalID, userInternalID string) error {
+func bumpVersion(ctx context.Context, tx *tracedTx, prInternalID string, expectedVersion int) (int, error) {
 	query := `
-		INSERT INTO pr_reviewers (pull_request_id, user_id)
-		VALUES ($1, $2)
-		ON CONFLICT (pull_request_id, user_id) DO NOTHING
+		UPDATE pull_requests
+		SET version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR (version = $2 AND status = 'OPEN'))
+		RETURNING version
 	`
-	_, err := r.db.Exec(query, prInternalID, userInternalID)
-	return err
+	var version int
+	err := tx.GetContext(ctx, &version, query, prInternalID, expectedVersion)
+	return version, err
 }
 
-func (r *pullRequestRepository) RemoveReviewer(prInternalID, userInternalID string) error {
+func deleteReviewer(ctx context.Context, tx *tracedTx, prInternalID, userInternalID, reason string) error {
 	query := `DELETE FROM pr_reviewers WHERE pull_request_id = $1 AND user_id = $2`
-	_, err := r.db.Exec(query, prInternalID, userInternalID)
+	if _, err := tx.ExecContext(ctx, query, prInternalID, userInternalID); err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE pr_reviewer_history
+		SET removed_at = NOW(), removed_action = $3
+		WHERE pull_request_id = $1 AND user_id = $2 AND removed_at IS NULL
+	`
+	_, err := tx.ExecContext(ctx, query, prInternalID, userInternalID, reason)
 	return err
 }
 
-func (r *pullRequestRepository) GetReviewerUserIDs(prInternalID string) ([]string, error) {
+func (r *pullRequestRepository) GetReviewerUserIDs(ctx context.Context, prInternalID string) ([]string, error) {
 	query := `
 		SELECT u.user_id
 		FROM pr_reviewers pr
@@ -139,7 +207,7 @@ func (r *pullRequestRepository) GetReviewerUserIDs(prInternalID string) ([]strin
 		ORDER BY pr.assigned_at
 	`
 	var reviewers []string
-	err := r.db.Select(&reviewers, query, prInternalID)
+	err := r.db.SelectContext(ctx, &reviewers, query, prInternalID)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +219,7 @@ func (r *pullRequestRepository) GetReviewerUserIDs(prInternalID string) ([]strin
 	return reviewers, nil
 }
 
-func (r *pullRequestRepository) GetPRsByReviewerUserID(userID string) ([]model.PullRequestShort, error) {
+func (r *pullRequestRepository) GetPRsByReviewerUserID(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
 	query := `
 		SELECT pr.pull_request_id, pr.pull_request_name, u.user_id as author_id, pr.status
 		FROM pull_requests pr
@@ -162,7 +230,7 @@ func (r *pullRequestRepository) GetPRsByReviewerUserID(userID string) ([]model.P
 		ORDER BY pr.created_at DESC
 	`
 	var prs []model.PullRequestShort
-	err := r.db.Select(&prs, query, userID)
+	err := r.db.SelectContext(ctx, &prs, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -174,7 +242,37 @@ func (r *pullRequestRepository) GetPRsByReviewerUserID(userID string) ([]model.P
 	return prs, nil
 }
 
-func (r *pullRequestRepository) IsReviewerAssigned(prInternalID, userInternalID string) (bool, error) {
+// StreamPRsByReviewerUserID читает PR ревьювера построчно с курсора.
+func (r *pullRequestRepository) StreamPRsByReviewerUserID(ctx context.Context, userID string, fn func(model.PullRequestShort) error) error {
+	query := `
+		SELECT pr.pull_request_id, pr.pull_request_name, u.user_id as author_id, pr.status
+		FROM pull_requests pr
+		INNER JOIN pr_reviewers rev ON pr.id = rev.pull_request_id
+		INNER JOIN users reviewer ON rev.user_id = reviewer.id
+		INNER JOIN users u ON pr.author_id = u.id
+		WHERE reviewer.user_id = $1
+		ORDER BY pr.created_at DESC
+	`
+	rows, err := r.db.QueryxContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pr model.PullRequestShort
+		if err := rows.StructScan(&pr); err != nil {
+			return err
+		}
+		if err := fn(pr); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *pullRequestRepository) IsReviewerAssigned(ctx context.Context, prInternalID, userInternalID string) (bool, error) {
 	var exists bool
 	query := `
 		SELECT EXISTS(
@@ -182,43 +280,113 @@ func (r *pullRequestRepository) IsReviewerAssigned(prInternalID, userInternalID
 			WHERE pull_request_id = $1 AND user_id = $2
 		)
 	`
-	err := r.db.Get(&exists, query, prInternalID, userInternalID)
+	err := r.db.GetContext(ctx, &exists, query, prInternalID, userInternalID)
 	return exists, err
 }
 
-func (r *pullRequestRepository) AssignReviewersBatch(prInternalID string, userInternalIDs []string) error {
+// AssignReviewersBatch назначает первых ревьюверов при создании PR;
+// версия не меняется, т.к. это часть создания.
+func (r *pullRequestRepository) AssignReviewersBatch(ctx context.Context, prInternalID string, userInternalIDs []string, action string) error {
 	if len(userInternalIDs) == 0 {
 		return nil
 	}
 	
-	tx, err := r.db.Beginx()
+	tx, err := r.db.BeginTxx(ctx)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
+
+	if err := insertReviewers(ctx, tx, prInternalID, userInternalIDs, action); err != nil {
+		return err
+	}
 	
+	return tx.Commit()
+}
+
+func insertReviewers(ctx context.Context, tx *tracedTx, prInternalID string, userInternalIDs []string, action string) error {
 	query := `
 		INSERT INTO pr_reviewers (pull_request_id, user_id)
 		VALUES ($1, $2)
 		ON CONFLICT (pull_request_id, user_id) DO NOTHING
 	`
+	historyQuery := `
+		INSERT INTO pr_reviewer_history (pull_request_id, user_id, assigned_action)
+		VALUES ($1, $2, $3)
+	`
 	
 	for _, userID := range userInternalIDs {
-		if _, err := tx.Exec(query, prInternalID, userID); err != nil {
+		result, err := tx.ExecContext(ctx, query, prInternalID, userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, historyQuery, prInternalID, userID, action); err != nil {
 			return err
 		}
 	}
-	
+
+	return nil
+}
+
+func (r *pullRequestRepository) RemoveAllReviewers(ctx context.Context, prInternalID, reason string) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := bumpVersion(ctx, tx, prInternalID, 0); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pr_reviewers WHERE pull_request_id = $1`, prInternalID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE pr_reviewer_history
+		SET removed_at = NOW(), removed_action = $2
+		WHERE pull_request_id = $1 AND removed_at IS NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, prInternalID, reason); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-func (r *pullRequestRepository) RemoveAllReviewers(prInternalID string) error {
-	query := `DELETE FROM pr_reviewers WHERE pull_request_id = $1`
-	_, err := r.db.Exec(query, prInternalID)
-	return err
+func (r *pullRequestRepository) GetReviewerHistory(ctx context.Context, prInternalID string) ([]model.ReviewerAssignment, error) {
+	query := `
+		SELECT u.user_id, h.assigned_at, h.assigned_action, h.removed_at,
+		       COALESCE(h.removed_action, '') AS removed_action
+		FROM pr_reviewer_history h
+		JOIN users u ON h.user_id = u.id
+		WHERE h.pull_request_id = $1
+		ORDER BY h.assigned_at, h.id
+	`
+	var history []model.ReviewerAssignment
+	err := r.db.SelectContext(ctx, &history, query, prInternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if history == nil {
+		history = []model.ReviewerAssignment{}
+	}
+
+	return history, nil
 }
 
-func (r *pullRequestRepository) GetReviewerAssignmentCount(userInternalID string) (int, error) {
+func (r *pullRequestRepository) GetReviewerAssignmentCount(ctx context.Context, userInternalID string) (int, error) {
 	var count int
 	query := `
 		SELECT COUNT(DISTINCT pr.id)
@@ -226,6 +394,81 @@ func (r *pullRequestRepository) GetReviewerAssignmentCount(userInternalID string
 		JOIN pull_requests pr ON rev.pull_request_id = pr.id
 		WHERE rev.user_id = $1 AND pr.status = 'OPEN'
 	`
-	err := r.db.Get(&count, query, userInternalID)
+	err := r.db.GetContext(ctx, &count, query, userInternalID)
 	return count, err
+}
+
+func (r *pullRequestRepository) AddReviewDecision(ctx context.Context, prInternalID, userInternalID, decision string) (time.Time, error) {
+	query := `
+		INSERT INTO review_decisions (pull_request_id, user_id, decision)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`
+	var createdAt time.Time
+	err := r.db.GetContext(ctx, &createdAt, query, prInternalID, userInternalID, decision)
+	return createdAt, err
+}
+
+// GetStaleReviews возвращает назначения на открытые PR старше SLA команды.
+// Для NUDGE напоминание повторяется раз в SLA, остальные политики срабатывают один раз.
+func (r *pullRequestRepository) GetStaleReviews(ctx context.Context, defaultSLAHours, limit int) ([]model.StaleReview, error) {
+	query := `
+		SELECT pr.id AS pr_internal_id, pr.pull_request_id, pr.pull_request_name,
+		       pr.author_id AS author_internal_id, pr.team_id, t.team_name,
+		       rev.user_id AS reviewer_internal_id, u.user_id AS reviewer_user_id,
+		       rev.assigned_at, rev.escalated_at, t.stale_policy, sla.hours AS sla_hours
+		FROM pr_reviewers rev
+		JOIN pull_requests pr ON rev.pull_request_id = pr.id
+		JOIN teams t ON pr.team_id = t.id
+		JOIN users u ON rev.user_id = u.id
+		CROSS JOIN LATERAL (SELECT COALESCE(t.review_sla_hours, $1) AS hours) sla
+		WHERE pr.status = 'OPEN'
+		  AND NOT t.is_archived
+		  AND sla.hours > 0
+		  AND rev.assigned_at < NOW() - make_interval(hours => sla.hours)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM review_decisions d
+		      WHERE d.pull_request_id = pr.id AND d.user_id = rev.user_id AND d.created_at >= rev.assigned_at
+		  )
+		  AND (
+		      rev.escalated_at IS NULL
+		      OR (t.stale_policy = 'NUDGE' AND rev.escalated_at < NOW() - make_interval(hours => sla.hours))
+		  )
+		ORDER BY rev.assigned_at
+		LIMIT $2
+	`
+	var reviews []model.StaleReview
+	err := r.db.SelectContext(ctx, &reviews, query, defaultSLAHours, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviews == nil {
+		reviews = []model.StaleReview{}
+	}
+
+	return reviews, nil
+}
+
+const markReviewEscalatedQuery = `
+	UPDATE pr_reviewers
+	SET escalated_at = NOW()
+	WHERE pull_request_id = $1 AND user_id = $2
+`
+
+func (r *pullRequestRepository) MarkReviewEscalated(ctx context.Context, prInternalID, userInternalID string) error {
+	_, err := r.db.ExecContext(ctx, markReviewEscalatedQuery, prInternalID, userInternalID)
+	return err
+}
+
+// AssignEscalationReviewer добавляет ревьювера к PR и отмечает просроченное
+// назначение escalatedUserInternalID в одной транзакции с увеличением версии PR.
+func (r *pullRequestRepository) AssignEscalationReviewer(ctx context.Context, prInternalID, escalatedUserInternalID, userInternalID, action string, expectedVersion int) (int, error) {
+	return r.changeReviewers(ctx, prInternalID, expectedVersion, func(tx *tracedTx) error {
+		if err := insertReviewers(ctx, tx, prInternalID, []string{userInternalID}, action); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, markReviewEscalatedQuery, prInternalID, escalatedUserInternalID)
+		return err
+	})
 }
\ No newline at end of file
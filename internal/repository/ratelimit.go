@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"github.com/jmoiron/sqlx"
+)
+
+type RateLimitRepository interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error)
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+type rateLimitRepository struct {
+	db *tracedDB
+}
+
+func NewRateLimitRepository(db *sqlx.DB, queryTimeout time.Duration) RateLimitRepository {
+	return &rateLimitRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+// Take пополняет bucket за прошедшее время и забирает токен, если он есть.
+// Всё делается одним upsert'ом под блокировкой строки, поэтому параллельные
+// запросы с разных реплик не расходуют один токен дважды.
+func (r *rateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	query := `
+		INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+		VALUES ($1, $3::float8 - 1, TRUE, NOW())
+		ON CONFLICT (bucket_key) DO UPDATE
+		SET tokens = CASE
+		        WHEN LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $2::float8) >= 1
+		        THEN LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $2::float8) - 1
+		        ELSE LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $2::float8)
+		    END,
+		    allowed = LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $2::float8) >= 1,
+		    updated_at = NOW()
+		RETURNING tokens, allowed
+	`
+	var row struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, key, rate, burst); err != nil {
+		return 0, false, err
+	}
+	return row.Tokens, row.Allowed, nil
+}
+
+func (r *rateLimitRepository) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	query := `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1 * INTERVAL '1 second'`
+	result, err := r.db.ExecContext(ctx, query, idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -1,19 +1,43 @@
 package repository
 
-import "github.com/jmoiron/sqlx"
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
 
 type Repositories struct {
 	Team        TeamRepository
 	User        UserRepository
+	Membership  MembershipRepository
 	PullRequest PullRequestRepository
 	Stats       StatsRepository
+	Audit       AuditRepository
+	Lock        LockRepository
+	Idempotency IdempotencyRepository
+	APIToken    APITokenRepository
+	Role        RoleRepository
+	RateLimit   RateLimitRepository
+	ForgeUser   ForgeUserRepository
+	Webhook     WebhookRepository
 }
 
-func NewRepositories(db *sqlx.DB) *Repositories {
+// NewRepositories создаёт репозитории; queryTimeout ограничивает каждый
+// запрос к БД, 0 — без ограничения.
+func NewRepositories(db *sqlx.DB, queryTimeout time.Duration) *Repositories {
 	return &Repositories{
-		Team:        NewTeamRepository(db),
-		User:        NewUserRepository(db),
-		PullRequest: NewPullRequestRepository(db),
-		Stats:       NewStatsRepository(db),
+		Team:        NewTeamRepository(db, queryTimeout),
+		User:        NewUserRepository(db, queryTimeout),
+		Membership:  NewMembershipRepository(db, queryTimeout),
+		PullRequest: NewPullRequestRepository(db, queryTimeout),
+		Stats:       NewStatsRepository(db, queryTimeout),
+		Audit:       NewAuditRepository(db, queryTimeout),
+		Lock:        NewLockRepository(db, queryTimeout),
+		Idempotency: NewIdempotencyRepository(db, queryTimeout),
+		APIToken:    NewAPITokenRepository(db, queryTimeout),
+		Role:        NewRoleRepository(db, queryTimeout),
+		RateLimit:   NewRateLimitRepository(db, queryTimeout),
+		ForgeUser:   NewForgeUserRepository(db, queryTimeout),
+		Webhook:     NewWebhookRepository(db, queryTimeout),
 	}
 }
\ No newline at end of file
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type RoleRepository interface {
+	Grant(ctx context.Context, userInternalID, role string, teamID *string) (bool, error)
+	Revoke(ctx context.Context, userInternalID, role string, teamID *string) (bool, error)
+	ListByUser(ctx context.Context, userInternalID string) ([]model.UserRole, error)
+	HasRole(ctx context.Context, userID, role string, teamID *string) (bool, error)
+}
+
+type roleRepository struct {
+	db *tracedDB
+}
+
+func NewRoleRepository(db *sqlx.DB, queryTimeout time.Duration) RoleRepository {
+	return &roleRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+// Grant выдаёт роль; false — роль у пользователя уже была.
+// teamID пуст для ролей уровня организации.
+func (r *roleRepository) Grant(ctx context.Context, userInternalID, role string, teamID *string) (bool, error) {
+	query := `
+		INSERT INTO user_roles (user_id, role, team_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+	result, err := r.db.ExecContext(ctx, query, userInternalID, role, teamID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *roleRepository) Revoke(ctx context.Context, userInternalID, role string, teamID *string) (bool, error) {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role = $2 AND team_id IS NOT DISTINCT FROM $3
+	`
+	result, err := r.db.ExecContext(ctx, query, userInternalID, role, teamID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func (r *roleRepository) ListByUser(ctx context.Context, userInternalID string) ([]model.UserRole, error) {
+	query := `
+		SELECT r.role, t.team_name, r.created_at
+		FROM user_roles r
+		LEFT JOIN teams t ON t.id = r.team_id
+		WHERE r.user_id = $1
+		ORDER BY r.role, t.team_name
+	`
+	roles := []model.UserRole{}
+	if err := r.db.SelectContext(ctx, &roles, query, userInternalID); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// HasRole проверяет роль по внешнему user_id; роли удалённых пользователей
+// не действуют.
+func (r *roleRepository) HasRole(ctx context.Context, userID, role string, teamID *string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM user_roles r
+			JOIN users u ON u.id = r.user_id
+			WHERE u.user_id = $1 AND u.deleted_at IS NULL
+			  AND r.role = $2 AND r.team_id IS NOT DISTINCT FROM $3
+		)
+	`
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, query, userID, role, teamID)
+	return exists, err
+}
@@ -1,91 +1,379 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
 )
 
+// Группировки для метрик времени ревью: ключ назначения и ключ PR.
+var turnaroundGroupings = map[string][2]string{
+	"user": {"ru.user_id", "au.user_id"},
+	"team": {"rt.team_name", "pt.team_name"},
+}
+
 type StatsRepository interface {
-	RecordAssignment(userInternalID, prInternalID string) error
-	GetUserStats() ([]struct {
-		UserID          string `db:"user_id"`
-		AssignmentCount int    `db:"assignment_count"`
-	}, error)
-	GetPRStats() ([]struct {
-		PRID          string `db:"pr_id"`
-		ReviewerCount int    `db:"reviewer_count"`
-	}, error)
+	RecordAssignment(ctx context.Context, userInternalID, prInternalID string) error
+	GetUserStats(ctx context.Context, filter model.StatsFilter) ([]model.UserAssignmentStat, int, error)
+	GetPRStats(ctx context.Context, filter model.StatsFilter) ([]model.PRReviewerStat, int, error)
+	StreamUserStats(ctx context.Context, filter model.StatsFilter, fn func(model.UserAssignmentStat) error) error
+	StreamPRStats(ctx context.Context, filter model.StatsFilter, fn func(model.PRReviewerStat) error) error
+	GetTurnaround(ctx context.Context, groupBy string, from, to time.Time) ([]model.TurnaroundRow, error)
+	GetFairness(ctx context.Context, teamID string, from, to time.Time) ([]model.FairnessRow, error)
+	GetOpenReviewLoad(ctx context.Context) ([]model.OpenReviewLoad, error)
 }
 
 type statsRepository struct {
-	db *sqlx.DB
+	db *tracedDB
 }
 
-func NewStatsRepository(db *sqlx.DB) StatsRepository {
-	return &statsRepository{db: db}
+func NewStatsRepository(db *sqlx.DB, queryTimeout time.Duration) StatsRepository {
+	return &statsRepository{db: newTracedDB(db, queryTimeout)}
 }
 
-func (r *statsRepository) RecordAssignment(userInternalID, prInternalID string) error {
+func (r *statsRepository) RecordAssignment(ctx context.Context, userInternalID, prInternalID string) error {
 	query := `
 		INSERT INTO assignment_stats (user_id, pr_id)
 		VALUES ($1, $2)
 		ON CONFLICT (user_id, pr_id) DO NOTHING
 	`
-	_, err := r.db.Exec(query, userInternalID, prInternalID)
+	_, err := r.db.ExecContext(ctx, query, userInternalID, prInternalID)
 	return err
 }
 
-func (r *statsRepository) GetUserStats() ([]struct {
-	UserID          string `db:"user_id"`
-	AssignmentCount int    `db:"assignment_count"`
-}, error) {
-	query := `
-		SELECT u.user_id, COUNT(*) as assignment_count
+// statsBuckets — выражения группировки назначений по периодам.
+var statsBuckets = map[string]string{
+	"":     "NULL::timestamp",
+	"day":  "date_trunc('day', s.assigned_at)",
+	"week": "date_trunc('week', s.assigned_at)",
+}
+
+// statsWhere строит условия фильтра по assignment_stats s и pull_requests pr.
+func statsWhere(filter model.StatsFilter) (string, []interface{}) {
+	where := ` WHERE TRUE`
+	args := []interface{}{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(condition, len(args))
+	}
+
+	if !filter.From.IsZero() {
+		addCondition(` AND s.assigned_at >= $%d`, filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition(` AND s.assigned_at < $%d`, filter.To)
+	}
+	if filter.TeamID != "" {
+		addCondition(` AND pr.team_id = $%d`, filter.TeamID)
+	}
+	if filter.Status != "" {
+		addCondition(` AND pr.status = $%d`, filter.Status)
+	}
+
+	return where, args
+}
+
+// userStatsQuery возвращает сгруппированный запрос статистики по пользователям
+// без сортировки и пагинации.
+func userStatsQuery(filter model.StatsFilter) (string, []interface{}, error) {
+	bucket, ok := statsBuckets[filter.GroupBy]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown stats grouping %q", filter.GroupBy)
+	}
+
+	where, args := statsWhere(filter)
+	grouped := fmt.Sprintf(`
+		SELECT %s AS bucket, u.user_id, COUNT(*) AS assignment_count
 		FROM assignment_stats s
 		JOIN users u ON s.user_id = u.id
-		GROUP BY u.user_id
-		ORDER BY assignment_count DESC
-	`
-	var stats []struct {
-		UserID          string `db:"user_id"`
-		AssignmentCount int    `db:"assignment_count"`
+		JOIN pull_requests pr ON s.pr_id = pr.id`, bucket) + where + `
+		GROUP BY bucket, u.user_id`
+
+	return grouped, args, nil
+}
+
+// prStatsQuery — то же для статистики по PR.
+func prStatsQuery(filter model.StatsFilter) (string, []interface{}, error) {
+	bucket, ok := statsBuckets[filter.GroupBy]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown stats grouping %q", filter.GroupBy)
 	}
-	err := r.db.Select(&stats, query)
+
+	where, args := statsWhere(filter)
+	grouped := fmt.Sprintf(`
+		SELECT %s AS bucket, pr.pull_request_id AS pr_id, COUNT(*) AS reviewer_count
+		FROM assignment_stats s
+		JOIN pull_requests pr ON s.pr_id = pr.id`, bucket) + where + `
+		GROUP BY bucket, pr.pull_request_id`
+
+	return grouped, args, nil
+}
+
+// paginate добавляет LIMIT/OFFSET; нулевой лимит означает выборку целиком.
+func paginate(query string, args []interface{}, limit, offset int) (string, []interface{}) {
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(` OFFSET $%d`, len(args))
+	}
+	return query, args
+}
+
+const (
+	userStatsOrder = ` ORDER BY bucket, assignment_count DESC, u.user_id`
+	prStatsOrder   = ` ORDER BY bucket, reviewer_count DESC, pr.pull_request_id`
+)
+
+func (r *statsRepository) GetUserStats(ctx context.Context, filter model.StatsFilter) ([]model.UserAssignmentStat, int, error) {
+	grouped, args, err := userStatsQuery(filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+grouped+`) g`, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query, args := paginate(grouped+userStatsOrder, args, filter.Limit, filter.Offset)
+
+	var stats []model.UserAssignmentStat
+	if err := r.db.SelectContext(ctx, &stats, query, args...); err != nil {
+		return nil, 0, err
 	}
+
 	if stats == nil {
-		stats = []struct {
-			UserID          string `db:"user_id"`
-			AssignmentCount int    `db:"assignment_count"`
-		}{}
+		stats = []model.UserAssignmentStat{}
 	}
-	return stats, nil
+
+	return stats, total, nil
 }
 
-func (r *statsRepository) GetPRStats() ([]struct {
-	PRID          string `db:"pr_id"`
-	ReviewerCount int    `db:"reviewer_count"`
-}, error) {
+// StreamUserStats читает статистику по пользователям построчно с курсора,
+// не собирая её в память.
+func (r *statsRepository) StreamUserStats(ctx context.Context, filter model.StatsFilter, fn func(model.UserAssignmentStat) error) error {
+	grouped, args, err := userStatsQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	query, args := paginate(grouped+userStatsOrder, args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat model.UserAssignmentStat
+		if err := rows.StructScan(&stat); err != nil {
+			return err
+		}
+		if err := fn(stat); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *statsRepository) GetPRStats(ctx context.Context, filter model.StatsFilter) ([]model.PRReviewerStat, int, error) {
+	grouped, args, err := prStatsQuery(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM (`+grouped+`) g`, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query, args := paginate(grouped+prStatsOrder, args, filter.Limit, filter.Offset)
+
+	var stats []model.PRReviewerStat
+	if err := r.db.SelectContext(ctx, &stats, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	if stats == nil {
+		stats = []model.PRReviewerStat{}
+	}
+
+	return stats, total, nil
+}
+
+// StreamPRStats читает статистику по PR построчно с курсора.
+func (r *statsRepository) StreamPRStats(ctx context.Context, filter model.StatsFilter, fn func(model.PRReviewerStat) error) error {
+	grouped, args, err := prStatsQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	query, args := paginate(grouped+prStatsOrder, args, filter.Limit, filter.Offset)
+
+	rows, err := r.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat model.PRReviewerStat
+		if err := rows.StructScan(&stat); err != nil {
+			return err
+		}
+		if err := fn(stat); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetTurnaround считает p50/p90 (в секундах) времени до первого решения и до
+// одобрения по каждому назначению, а также времени от открытия PR до merge.
+// Учитываются PR, созданные в окне [from, to).
+func (r *statsRepository) GetTurnaround(ctx context.Context, groupBy string, from, to time.Time) ([]model.TurnaroundRow, error) {
+	keys, ok := turnaroundGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown turnaround grouping %q", groupBy)
+	}
+
+	query := fmt.Sprintf(`
+		WITH assignments AS (
+			SELECT h.pull_request_id, h.user_id, h.assigned_at, pr.team_id,
+			       (SELECT MIN(d.created_at) FROM review_decisions d
+			        WHERE d.pull_request_id = h.pull_request_id AND d.user_id = h.user_id
+			          AND d.created_at >= h.assigned_at) AS decided_at,
+			       (SELECT MIN(d.created_at) FROM review_decisions d
+			        WHERE d.pull_request_id = h.pull_request_id AND d.user_id = h.user_id
+			          AND d.created_at >= h.assigned_at AND d.decision = 'APPROVED') AS approved_at
+			FROM pr_reviewer_history h
+			JOIN pull_requests pr ON h.pull_request_id = pr.id
+			WHERE pr.created_at >= $1 AND pr.created_at < $2
+		),
+		review_agg AS (
+			SELECT %[1]s AS key,
+			       COUNT(a.decided_at) AS decision_count,
+			       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM a.decided_at - a.assigned_at)) AS decision_p50,
+			       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM a.decided_at - a.assigned_at)) AS decision_p90,
+			       COUNT(a.approved_at) AS approval_count,
+			       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM a.approved_at - a.assigned_at)) AS approval_p50,
+			       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM a.approved_at - a.assigned_at)) AS approval_p90
+			FROM assignments a
+			JOIN users ru ON a.user_id = ru.id
+			LEFT JOIN teams rt ON a.team_id = rt.id
+			WHERE %[1]s IS NOT NULL
+			GROUP BY %[1]s
+		),
+		merge_agg AS (
+			SELECT %[2]s AS key,
+			       COUNT(pr.merged_at) AS merge_count,
+			       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)) AS merge_p50,
+			       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)) AS merge_p90
+			FROM pull_requests pr
+			JOIN users au ON pr.author_id = au.id
+			LEFT JOIN teams pt ON pr.team_id = pt.id
+			WHERE pr.created_at >= $1 AND pr.created_at < $2 AND pr.merged_at IS NOT NULL AND %[2]s IS NOT NULL
+			GROUP BY %[2]s
+		)
+		SELECT COALESCE(r.key, m.key) AS key,
+		       COALESCE(r.decision_count, 0) AS decision_count, r.decision_p50, r.decision_p90,
+		       COALESCE(r.approval_count, 0) AS approval_count, r.approval_p50, r.approval_p90,
+		       COALESCE(m.merge_count, 0) AS merge_count, m.merge_p50, m.merge_p90
+		FROM review_agg r
+		FULL OUTER JOIN merge_agg m ON r.key = m.key
+		ORDER BY key
+	`, keys[0], keys[1])
+
+	var rows []model.TurnaroundRow
+	if err := r.db.SelectContext(ctx, &rows, query, from, to); err != nil {
+		return nil, err
+	}
+
+	if rows == nil {
+		rows = []model.TurnaroundRow{}
+	}
+
+	return rows, nil
+}
+
+// GetFairness возвращает для каждого участника команды число назначений на
+// PR этой команды в окне [from, to) и время, которое он был активен в этом окне.
+func (r *statsRepository) GetFairness(ctx context.Context, teamID string, from, to time.Time) ([]model.FairnessRow, error) {
 	query := `
-		SELECT pr.pull_request_id as pr_id, COUNT(*) as reviewer_count
-		FROM assignment_stats s
-		JOIN pull_requests pr ON s.pr_id = pr.id
-		GROUP BY pr.pull_request_id
-		ORDER BY reviewer_count DESC
+		WITH periods AS (
+			SELECT user_id, is_active, created_at AS started_at,
+			       LEAD(created_at, 1, 'infinity'::timestamp)
+			           OVER (PARTITION BY user_id ORDER BY created_at, id) AS ended_at
+			FROM user_activity_events
+		),
+		active AS (
+			SELECT user_id,
+			       SUM(EXTRACT(EPOCH FROM LEAST(ended_at, $2) - GREATEST(started_at, $1))) AS active_seconds
+			FROM periods
+			WHERE is_active AND started_at < $2 AND ended_at > $1
+			GROUP BY user_id
+		),
+		assigned AS (
+			SELECT st.user_id, pr.team_id, COUNT(*) AS assignment_count
+			FROM assignment_stats st
+			JOIN pull_requests pr ON st.pr_id = pr.id
+			WHERE st.assigned_at >= $1 AND st.assigned_at < $2
+			GROUP BY st.user_id, pr.team_id
+		)
+		SELECT t.team_name, u.user_id,
+		       COALESCE(s.assignment_count, 0) AS assignment_count,
+		       a.active_seconds
+		FROM team_memberships tm
+		JOIN teams t ON tm.team_id = t.id
+		JOIN users u ON tm.user_id = u.id
+		JOIN active a ON a.user_id = u.id
+		LEFT JOIN assigned s ON s.user_id = u.id AND s.team_id = tm.team_id
+		WHERE u.deleted_at IS NULL AND ($3 = '' OR t.id::text = $3)
+		ORDER BY t.team_name, u.user_id
 	`
-	var stats []struct {
-		PRID          string `db:"pr_id"`
-		ReviewerCount int    `db:"reviewer_count"`
+
+	var rows []model.FairnessRow
+	if err := r.db.SelectContext(ctx, &rows, query, from, to, teamID); err != nil {
+		return nil, err
 	}
-	err := r.db.Select(&stats, query)
-	if err != nil {
+
+	if rows == nil {
+		rows = []model.FairnessRow{}
+	}
+
+	return rows, nil
+}
+
+// GetOpenReviewLoad возвращает число открытых ревью каждого пользователя
+// в разрезе команд PR.
+func (r *statsRepository) GetOpenReviewLoad(ctx context.Context) ([]model.OpenReviewLoad, error) {
+	query := `
+		SELECT t.team_name, u.user_id, COUNT(*) AS open_reviews
+		FROM pr_reviewers rev
+		JOIN pull_requests pr ON rev.pull_request_id = pr.id
+		JOIN teams t ON pr.team_id = t.id
+		JOIN users u ON rev.user_id = u.id
+		WHERE pr.status = 'OPEN'
+		GROUP BY t.team_name, u.user_id
+	`
+	var load []model.OpenReviewLoad
+	if err := r.db.SelectContext(ctx, &load, query); err != nil {
 		return nil, err
 	}
-	if stats == nil {
-		stats = []struct {
-			PRID          string `db:"pr_id"`
-			ReviewerCount int    `db:"reviewer_count"`
-		}{}
+
+	if load == nil {
+		load = []model.OpenReviewLoad{}
 	}
-	return stats, nil
-}
\ No newline at end of file
+
+	return load, nil
+}
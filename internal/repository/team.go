@@ -1,50 +1,374 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"time"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"assign-reviewers-for-pull-requests/internal/model"
 )
 
+// MaxTeamDepth ограничивает глубину иерархии команд.
+const MaxTeamDepth = 32
+
 type TeamRepository interface {
-	Create(teamName string) (string, error)
-	Exists(teamName string) (bool, error)
-	Get(teamName string) (*model.Team, error)
-	GetByID(teamID string) (*model.Team, error)
-	GetIDByName(teamName string) (string, error)
+	Create(ctx context.Context, teamName string) (string, error)
+	CreateWithMembers(ctx context.Context, team *model.Team, parentTeamID *string) (string, error)
+	Exists(ctx context.Context, teamName string) (bool, error)
+	Get(ctx context.Context, teamName string) (*model.Team, error)
+	GetByID(ctx context.Context, teamID string) (*model.Team, error)
+	GetIDByName(ctx context.Context, teamName string) (string, error)
+	LogMembershipChange(ctx context.Context, userInternalID, action string, fromTeamID, toTeamID *string) error
+	SetArchived(ctx context.Context, teamID string, isArchived bool) error
+	CountOpenPRs(ctx context.Context, teamID string) (int, error)
+	Delete(ctx context.Context, teamID string, targetTeamID *string) ([]string, error)
+	SetParent(ctx context.Context, teamID string, parentTeamID *string) error
+	Update(ctx context.Context, teamID string, update model.TeamUpdate) error
+	GetAncestors(ctx context.Context, teamID string) ([]model.TeamNode, error)
+	GetChildIDs(ctx context.Context, teamID string) ([]string, error)
 }
 
 type teamRepository struct {
-	db *sqlx.DB
+	db *tracedDB
 }
 
-func NewTeamRepository(db *sqlx.DB) TeamRepository {
-	return &teamRepository{db: db}
+func NewTeamRepository(db *sqlx.DB, queryTimeout time.Duration) TeamRepository {
+	return &teamRepository{db: newTracedDB(db, queryTimeout)}
 }
 
-func (r *teamRepository) Create(teamName string) (string, error) {
+func (r *teamRepository) Create(ctx context.Context, teamName string) (string, error) {
 	query := `INSERT INTO teams (team_name) VALUES ($1) RETURNING id`
 	var id string
-	err := r.db.Get(&id, query, teamName)
+	err := r.db.GetContext(ctx, &id, query, teamName)
 	return id, err
 }
 
-func (r *teamRepository) Exists(teamName string) (bool, error) {
+// UserDeletedError означает, что среди участников новой команды есть
+// удалённый пользователь.
+type UserDeletedError struct {
+	UserID string
+}
+
+func (e *UserDeletedError) Error() string {
+	return "user " + e.UserID + " is deleted"
+}
+
+// CreateWithMembers создаёт команду с родителем, настройками и участниками в
+// одной транзакции и возвращает её id. Существующие пользователи только
+// добавляются в команду, для удалённого возвращается *UserDeletedError.
+func (r *teamRepository) CreateWithMembers(ctx context.Context, team *model.Team, parentTeamID *string) (string, error) {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO teams (team_name, parent_team_id, max_open_reviews, review_sla_hours, stale_policy)
+		VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'NUDGE'))
+		RETURNING id
+	`
+	var id string
+	err = tx.GetContext(ctx, &id, query, team.TeamName, parentTeamID, team.MaxOpenReviews, team.ReviewSLAHours, team.StalePolicy)
+	if err != nil {
+		return "", err
+	}
+
+	for _, member := range team.Members {
+		if err := ensureUser(ctx, tx, member.UserID, member.Username, id, member.IsActive); err != nil {
+			if err == sql.ErrNoRows {
+				return "", &UserDeletedError{UserID: member.UserID}
+			}
+			return "", err
+		}
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *teamRepository) Exists(ctx context.Context, teamName string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`
-	err := r.db.Get(&exists, query, teamName)
+	err := r.db.GetContext(ctx, &exists, query, teamName)
 	return exists, err
 }
 
-func (r *teamRepository) GetIDByName(teamName string) (string, error) {
+func (r *teamRepository) GetIDByName(ctx context.Context, teamName string) (string, error) {
 	var id string
 	query := `SELECT id FROM teams WHERE team_name = $1`
-	err := r.db.Get(&id, query, teamName)
+	err := r.db.GetContext(ctx, &id, query, teamName)
 	return id, err
 }
 
-func (r *teamRepository) Get(teamName string) (*model.Team, error) {
-	teamID, err := r.GetIDByName(teamName)
+func (r *teamRepository) LogMembershipChange(ctx context.Context, userInternalID, action string, fromTeamID, toTeamID *string) error {
+	query := `
+		INSERT INTO team_membership_events (user_id, action, from_team_id, to_team_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := r.db.ExecContext(ctx, query, userInternalID, action, fromTeamID, toTeamID)
+	return err
+}
+
+func (r *teamRepository) SetArchived(ctx context.Context, teamID string, isArchived bool) error {
+	query := `
+		UPDATE teams
+		SET is_archived = $2,
+		    archived_at = CASE WHEN $2::boolean THEN NOW() ELSE NULL END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, teamID, isArchived)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *teamRepository) CountOpenPRs(ctx context.Context, teamID string) (int, error) {
+	var count int
+	query := `
+		SELECT COUNT(*)
+		FROM pull_requests pr
+		WHERE pr.team_id = $1 AND pr.status = 'OPEN'
+	`
+	err := r.db.GetContext(ctx, &count, query, teamID)
+	return count, err
+}
+
+// Delete переносит участников и PR в targetTeamID (или открепляет участников,
+// если он nil), записывает это в журнал членства и удаляет команду. Возвращает
+// внутренние идентификаторы затронутых пользователей.
+func (r *teamRepository) Delete(ctx context.Context, teamID string, targetTeamID *string) ([]string, error) {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	type memberRow struct {
+		UserID    string `db:"user_id"`
+		IsPrimary bool   `db:"is_primary"`
+	}
+
+	var members []memberRow
+	query := `SELECT user_id, is_primary FROM team_memberships WHERE team_id = $1`
+	if err := tx.SelectContext(ctx, &members, query, teamID); err != nil {
+		return nil, err
+	}
+
+	action := "REMOVE"
+	var lostPrimary []string
+
+	if targetTeamID != nil {
+		action = "MOVE"
+
+		var overlapping []memberRow
+		query = `
+			DELETE FROM team_memberships
+			WHERE team_id = $1
+			  AND user_id IN (SELECT user_id FROM team_memberships WHERE team_id = $2)
+			RETURNING user_id, is_primary
+		`
+		if err := tx.SelectContext(ctx, &overlapping, query, teamID, *targetTeamID); err != nil {
+			return nil, err
+		}
+
+		var primaryIDs []string
+		for _, m := range overlapping {
+			if m.IsPrimary {
+				primaryIDs = append(primaryIDs, m.UserID)
+			}
+		}
+		if len(primaryIDs) > 0 {
+			query = `
+				UPDATE team_memberships
+				SET is_primary = true, updated_at = NOW()
+				WHERE team_id = $1 AND user_id = ANY($2)
+			`
+			if _, err := tx.ExecContext(ctx, query, *targetTeamID, pq.Array(primaryIDs)); err != nil {
+				return nil, err
+			}
+		}
+
+		query = `
+			UPDATE team_memberships
+			SET team_id = $2, updated_at = NOW()
+			WHERE team_id = $1
+		`
+		if _, err := tx.ExecContext(ctx, query, teamID, *targetTeamID); err != nil {
+			return nil, err
+		}
+
+		query = `UPDATE pull_requests SET team_id = $2, version = version + 1 WHERE team_id = $1`
+		if _, err := tx.ExecContext(ctx, query, teamID, *targetTeamID); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM team_memberships WHERE team_id = $1`, teamID); err != nil {
+			return nil, err
+		}
+
+		for _, m := range members {
+			if m.IsPrimary {
+				lostPrimary = append(lostPrimary, m.UserID)
+			}
+		}
+	}
+
+	if err := promotePrimaryMemberships(ctx, tx, lostPrimary); err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, len(members))
+	logQuery := `
+		INSERT INTO team_membership_events (user_id, action, from_team_id, to_team_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	for i, m := range members {
+		if _, err := tx.ExecContext(ctx, logQuery, m.UserID, action, teamID, targetTeamID); err != nil {
+			return nil, err
+		}
+		userIDs[i] = m.UserID
+	}
+
+	query = `
+		UPDATE teams
+		SET parent_team_id = (SELECT parent_team_id FROM teams WHERE id = $1), updated_at = NOW()
+		WHERE parent_team_id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, teamID); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+func (r *teamRepository) SetParent(ctx context.Context, teamID string, parentTeamID *string) error {
+	query := `UPDATE teams SET parent_team_id = $2, updated_at = NOW() WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, teamID, parentTeamID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Update применяет все изменения команды одним запросом.
+func (r *teamRepository) Update(ctx context.Context, teamID string, update model.TeamUpdate) error {
+	query := `
+		UPDATE teams
+		SET team_name = COALESCE($2, team_name),
+		    parent_team_id = CASE WHEN $3 THEN $4::uuid ELSE parent_team_id END,
+		    max_open_reviews = CASE WHEN $5 THEN $6::integer ELSE max_open_reviews END,
+		    review_sla_hours = CASE WHEN $7 THEN $8::integer ELSE review_sla_hours END,
+		    stale_policy = COALESCE($9, stale_policy),
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, teamID, update.TeamName,
+		update.SetParent, update.ParentTeamID,
+		update.SetMaxOpenReviews, update.MaxOpenReviews,
+		update.SetReviewSLA, update.ReviewSLAHours,
+		update.StalePolicy,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAncestors возвращает цепочку от самой команды (depth = 0) до корня дерева.
+func (r *teamRepository) GetAncestors(ctx context.Context, teamID string) ([]model.TeamNode, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, team_name, parent_team_id, max_open_reviews, is_archived, 0 AS depth
+			FROM teams
+			WHERE id = $1
+			UNION ALL
+			SELECT t.id, t.team_name, t.parent_team_id, t.max_open_reviews, t.is_archived, c.depth + 1
+			FROM teams t
+			JOIN chain c ON t.id = c.parent_team_id
+			WHERE c.depth < $2
+		)
+		SELECT id, team_name, COALESCE(parent_team_id::text, '') AS parent_team_id,
+		       max_open_reviews, is_archived, depth
+		FROM chain
+		ORDER BY depth
+	`
+	var nodes []model.TeamNode
+	err := r.db.SelectContext(ctx, &nodes, query, teamID, MaxTeamDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes == nil {
+		nodes = []model.TeamNode{}
+	}
+
+	return nodes, nil
+}
+
+func (r *teamRepository) GetChildIDs(ctx context.Context, teamID string) ([]string, error) {
+	query := `SELECT id FROM teams WHERE parent_team_id = $1 ORDER BY team_name`
+	var ids []string
+	err := r.db.SelectContext(ctx, &ids, query, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ids == nil {
+		ids = []string{}
+	}
+
+	return ids, nil
+}
+
+func (r *teamRepository) Get(ctx context.Context, teamName string) (*model.Team, error) {
+	teamID, err := r.GetIDByName(ctx, teamName)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
@@ -52,38 +376,67 @@ func (r *teamRepository) Get(teamName string) (*model.Team, error) {
 		return nil, err
 	}
 
-	return r.GetByID(teamID)
+	return r.GetByID(ctx, teamID)
 }
 
-func (r *teamRepository) GetByID(teamID string) (*model.Team, error) {
-	query := `SELECT id, team_name FROM teams WHERE id = $1`
+func (r *teamRepository) GetByID(ctx context.Context, teamID string) (*model.Team, error) {
+	query := `
+		SELECT t.id, t.team_name, COALESCE(p.team_name, '') AS parent_team_name,
+		       t.max_open_reviews, t.review_sla_hours, t.stale_policy, t.is_archived
+		FROM teams t
+		LEFT JOIN teams p ON t.parent_team_id = p.id
+		WHERE t.id = $1
+	`
 	var team struct {
-		ID       string `db:"id"`
-		TeamName string `db:"team_name"`
+		ID             string        `db:"id"`
+		TeamName       string        `db:"team_name"`
+		ParentTeamName string        `db:"parent_team_name"`
+		MaxOpenReviews sql.NullInt64 `db:"max_open_reviews"`
+		ReviewSLAHours sql.NullInt64 `db:"review_sla_hours"`
+		StalePolicy    string        `db:"stale_policy"`
+		IsArchived     bool          `db:"is_archived"`
 	}
-	err := r.db.Get(&team, query, teamID)
+	err := r.db.GetContext(ctx, &team, query, teamID)
 	if err != nil {
 		return nil, err
 	}
 
-	members, err := r.getMembersByTeamID(teamID)
+	members, err := r.getMembersByTeamID(ctx, teamID)
 	if err != nil {
 		return nil, err
 	}
 
+	var maxOpenReviews *int
+	if team.MaxOpenReviews.Valid {
+		value := int(team.MaxOpenReviews.Int64)
+		maxOpenReviews = &value
+	}
+
+	var reviewSLAHours *int
+	if team.ReviewSLAHours.Valid {
+		value := int(team.ReviewSLAHours.Int64)
+		reviewSLAHours = &value
+	}
+
 	return &model.Team{
-		ID:       team.ID,
-		TeamName: team.TeamName,
-		Members:  members,
+		ID:             team.ID,
+		TeamName:       team.TeamName,
+		ParentTeamName: team.ParentTeamName,
+		MaxOpenReviews: maxOpenReviews,
+		ReviewSLAHours: reviewSLAHours,
+		StalePolicy:    team.StalePolicy,
+		IsArchived:     team.IsArchived,
+		Members:        members,
 	}, nil
 }
 
-func (r *teamRepository) getMembersByTeamID(teamID string) ([]model.TeamMember, error) {
+func (r *teamRepository) getMembersByTeamID(ctx context.Context, teamID string) ([]model.TeamMember, error) {
 	query := `
-		SELECT user_id, username, is_active
-		FROM users
-		WHERE team_id = $1
-		ORDER BY username
+		SELECT u.user_id, u.username, u.is_active AND m.is_active AS is_active
+		FROM team_memberships m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.team_id = $1
+		ORDER BY u.username
 	`
 	
 	type userRow struct {
@@ -93,7 +446,7 @@ func (r *teamRepository) getMembersByTeamID(teamID string) ([]model.TeamMember,
 	}
 	
 	var rows []userRow
-	err := r.db.Select(&rows, query, teamID)
+	err := r.db.SelectContext(ctx, &rows, query, teamID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type APITokenRepository interface {
+	Create(ctx context.Context, token *model.APIToken, tokenHash string) error
+	GetActiveByHash(ctx context.Context, tokenHash string) (*model.APIToken, error)
+	List(ctx context.Context) ([]model.APIToken, error)
+	Revoke(ctx context.Context, id string) (*model.APIToken, error)
+	TouchLastUsed(ctx context.Context, id string) error
+}
+
+type apiTokenRepository struct {
+	db *tracedDB
+}
+
+func NewAPITokenRepository(db *sqlx.DB, queryTimeout time.Duration) APITokenRepository {
+	return &apiTokenRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+const apiTokenColumns = `
+	t.id, t.name, t.scopes, u.user_id, t.created_at, t.expires_at, t.last_used_at, t.revoked_at
+`
+
+func (r *apiTokenRepository) Create(ctx context.Context, token *model.APIToken, tokenHash string) error {
+	query := `
+		INSERT INTO api_tokens (name, token_hash, scopes, user_id, expires_at)
+		VALUES ($1, $2, $3, (SELECT id FROM users WHERE user_id = $4), $5)
+		RETURNING id, created_at
+	`
+	return r.db.GetContext(ctx, token, query, token.Name, tokenHash, token.Scopes, token.UserID, token.ExpiresAt)
+}
+
+// GetActiveByHash ищет токен, который не отозван и не истёк.
+func (r *apiTokenRepository) GetActiveByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens t
+		LEFT JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		  AND t.revoked_at IS NULL
+		  AND (t.expires_at IS NULL OR t.expires_at > NOW())
+	`
+	var token model.APIToken
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) List(ctx context.Context) ([]model.APIToken, error) {
+	query := `
+		SELECT ` + apiTokenColumns + `
+		FROM api_tokens t
+		LEFT JOIN users u ON u.id = t.user_id
+		ORDER BY t.created_at, t.id
+	`
+	tokens := []model.APIToken{}
+	if err := r.db.SelectContext(ctx, &tokens, query); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke отзывает токен; для неизвестного или уже отозванного токена
+// возвращает sql.ErrNoRows.
+func (r *apiTokenRepository) Revoke(ctx context.Context, id string) (*model.APIToken, error) {
+	query := `
+		UPDATE api_tokens t SET revoked_at = NOW()
+		FROM api_tokens prev
+		LEFT JOIN users u ON u.id = prev.user_id
+		WHERE t.id = $1 AND prev.id = t.id AND t.revoked_at IS NULL
+		RETURNING ` + apiTokenColumns
+	var token model.APIToken
+	if err := r.db.GetContext(ctx, &token, query, id); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// TouchLastUsed обновляет время последнего использования не чаще раза в
+// минуту, чтобы не писать в БД на каждый запрос.
+func (r *apiTokenRepository) TouchLastUsed(ctx context.Context, id string) error {
+	query := `
+		UPDATE api_tokens SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
@@ -1,81 +1,132 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"assign-reviewers-for-pull-requests/internal/model"
 )
 
+const userSelect = `
+	SELECT u.id, u.user_id, u.username, COALESCE(m.team_id::text, '') AS team_id,
+	       COALESCE(t.team_name, '') AS team_name, COALESCE(t.is_archived, false) AS team_archived,
+	       u.is_active, u.attributes
+	FROM users u
+	LEFT JOIN team_memberships m ON m.user_id = u.id AND m.is_primary
+	LEFT JOIN teams t ON m.team_id = t.id
+`
+
 type UserRepository interface {
-	Upsert(userID, username, teamID string, isActive bool) error
-	GetByUserID(userID string) (*model.User, error)
-	GetByID(id string) (*model.User, error)
-	GetActiveByTeamID(teamID string, excludeIDs []string) ([]model.User, error)
-	SetIsActive(userID string, isActive bool) error
+	Ensure(ctx context.Context, userID, username, teamID string, isActive bool) error
+	GetByUserID(ctx context.Context, userID string) (*model.User, error)
+	GetByID(ctx context.Context, id string) (*model.User, error)
+	GetActiveByTeamID(ctx context.Context, teamID string, excludeIDs []string) ([]model.User, error)
+	SetIsActive(ctx context.Context, userID string, isActive bool) error
+	Update(ctx context.Context, userID string, username *string, attributes model.Attributes) error
+	SoftDelete(ctx context.Context, userID string) ([]string, error)
+	List(ctx context.Context, filter model.UserFilter) ([]model.User, int, error)
 	
-	GetIDByUserID(userID string) (string, error)
+	GetIDByUserID(ctx context.Context, userID string) (string, error)
 }
 
 type userRepository struct {
-	db *sqlx.DB
+	db *tracedDB
 }
 
-func NewUserRepository(db *sqlx.DB) UserRepository {
-	return &userRepository{db: db}
+func NewUserRepository(db *sqlx.DB, queryTimeout time.Duration) UserRepository {
+	return &userRepository{db: newTracedDB(db, queryTimeout)}
 }
 
-func (r *userRepository) Upsert(userID, username, teamID string, isActive bool) error {
-	query := `
-		INSERT INTO users (user_id, username, team_id, is_active)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (user_id) 
-		DO UPDATE SET 
-			username = EXCLUDED.username,
-			team_id = EXCLUDED.team_id,
-			is_active = EXCLUDED.is_active,
-			updated_at = NOW()
-	`
-	_, err := r.db.Exec(query, userID, username, teamID, isActive)
-	return err
+// Ensure создаёт пользователя, если его ещё нет, и добавляет в команду teamID
+// (если она задана). Имя и активность существующего пользователя не меняются;
+// для удалённого пользователя возвращается sql.ErrNoRows.
+func (r *userRepository) Ensure(ctx context.Context, userID, username, teamID string, isActive bool) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureUser(ctx, tx, userID, username, teamID, isActive); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *userRepository) GetByUserID(userID string) (*model.User, error) {
+func ensureUser(ctx context.Context, tx *tracedTx, userID, username, teamID string, isActive bool) error {
+	var user struct {
+		ID       string `db:"id"`
+		Inserted bool   `db:"inserted"`
+	}
 	query := `
-		SELECT u.id, u.user_id, u.username, u.team_id, t.team_name, u.is_active
-		FROM users u
-		LEFT JOIN teams t ON u.team_id = t.id
-		WHERE u.user_id = $1
+		INSERT INTO users (user_id, username, is_active)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET user_id = users.user_id
+		WHERE users.deleted_at IS NULL
+		RETURNING id, (xmax = 0) AS inserted
 	`
+	if err := tx.GetContext(ctx, &user, query, userID, username, isActive); err != nil {
+		return err
+	}
+	id := user.ID
+
+	if user.Inserted {
+		if err := recordActivity(ctx, tx, id, isActive); err != nil {
+			return err
+		}
+	}
+
+	if teamID != "" {
+		membershipQuery := `
+			INSERT INTO team_memberships (user_id, team_id, is_primary, is_active)
+			VALUES ($1, $2, NOT EXISTS (
+				SELECT 1 FROM team_memberships WHERE user_id = $1 AND is_primary
+			), $3)
+			ON CONFLICT (user_id, team_id)
+			DO UPDATE SET
+				is_active = EXCLUDED.is_active,
+				updated_at = NOW()
+		`
+		if _, err := tx.ExecContext(ctx, membershipQuery, id, teamID, isActive); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetByUserID(ctx context.Context, userID string) (*model.User, error) {
+	query := userSelect + `WHERE u.user_id = $1 AND u.deleted_at IS NULL`
 	var user model.User
-	err := r.db.Get(&user, query, userID)
+	err := r.db.GetContext(ctx, &user, query, userID)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetByID(id string) (*model.User, error) {
-	query := `
-		SELECT u.id, u.user_id, u.username, u.team_id, t.team_name, u.is_active
-		FROM users u
-		LEFT JOIN teams t ON u.team_id = t.id
-		WHERE u.id = $1
-	`
+func (r *userRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+	query := userSelect + `WHERE u.id = $1`
 	var user model.User
-	err := r.db.Get(&user, query, id)
+	err := r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetActiveByTeamID(teamID string, excludeIDs []string) ([]model.User, error) {
+func (r *userRepository) GetActiveByTeamID(ctx context.Context, teamID string, excludeIDs []string) ([]model.User, error) {
 	query := `
-		SELECT u.id, u.user_id, u.username, u.team_id, t.team_name, u.is_active
+		SELECT u.id, u.user_id, u.username, m.team_id, t.team_name, u.is_active
 		FROM users u
-		LEFT JOIN teams t ON u.team_id = t.id
-		WHERE u.team_id = $1 AND u.is_active = true
+		JOIN team_memberships m ON m.user_id = u.id
+		JOIN teams t ON m.team_id = t.id
+		WHERE m.team_id = $1 AND u.is_active = true AND m.is_active = true AND t.is_archived = false
 	`
 	
 	args := []interface{}{teamID}
@@ -88,7 +139,7 @@ func (r *userRepository) GetActiveByTeamID(teamID string, excludeIDs []string) (
 	query += ` ORDER BY u.username`
 	
 	var users []model.User
-	err := r.db.Select(&users, query, args...)
+	err := r.db.SelectContext(ctx, &users, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,13 +151,69 @@ func (r *userRepository) GetActiveByTeamID(teamID string, excludeIDs []string) (
 	return users, nil
 }
 
-func (r *userRepository) SetIsActive(userID string, isActive bool) error {
+func (r *userRepository) SetIsActive(ctx context.Context, userID string, isActive bool) error {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id string
 	query := `
 		UPDATE users
 		SET is_active = $2, updated_at = NOW()
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`
+	if err := tx.GetContext(ctx, &id, query, userID, isActive); err != nil {
+		return err
+	}
+
+	if err := recordActivity(ctx, tx, id, isActive); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// recordActivity пишет смену активности пользователя, если она отличается
+// от последней записанной. По этим событиям считается время активности.
+func recordActivity(ctx context.Context, tx *tracedTx, userInternalID string, isActive bool) error {
+	query := `
+		INSERT INTO user_activity_events (user_id, is_active)
+		SELECT $1, $2
+		WHERE $2 IS DISTINCT FROM (
+			SELECT is_active FROM user_activity_events
+			WHERE user_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		)
+	`
+	_, err := tx.ExecContext(ctx, query, userInternalID, isActive)
+	return err
+}
+
+func (r *userRepository) GetIDByUserID(ctx context.Context, userID string) (string, error) {
+	var id string
+	query := `SELECT id FROM users WHERE user_id = $1 AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &id, query, userID)
+	return id, err
+}
+
+func (r *userRepository) Update(ctx context.Context, userID string, username *string, attributes model.Attributes) error {
+	query := `
+		UPDATE users
+		SET username = COALESCE($2, username),
+		    attributes = COALESCE($3::jsonb, attributes),
+		    updated_at = NOW()
+		WHERE user_id = $1 AND deleted_at IS NULL
 	`
-	result, err := r.db.Exec(query, userID, isActive)
+	var attrs interface{}
+	if attributes != nil {
+		attrs = attributes
+	}
+
+	result, err := r.db.ExecContext(ctx, query, userID, username, attrs)
 	if err != nil {
 		return err
 	}
@@ -122,9 +229,86 @@ func (r *userRepository) SetIsActive(userID string, isActive bool) error {
 	return nil
 }
 
-func (r *userRepository) GetIDByUserID(userID string) (string, error) {
+// SoftDelete деактивирует пользователя, удаляет его членство в командах и
+// помечает удалённым. Возвращает команды, из которых он был удалён.
+func (r *userRepository) SoftDelete(ctx context.Context, userID string) ([]string, error) {
+	tx, err := r.db.BeginTxx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var id string
-	query := `SELECT id FROM users WHERE user_id = $1`
-	err := r.db.Get(&id, query, userID)
-	return id, err
-}
\ No newline at end of file
+	query := `
+		UPDATE users
+		SET is_active = false, deleted_at = NOW(), updated_at = NOW()
+		WHERE user_id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`
+	if err := tx.GetContext(ctx, &id, query, userID); err != nil {
+		return nil, err
+	}
+
+	if err := recordActivity(ctx, tx, id, false); err != nil {
+		return nil, err
+	}
+
+	var teamIDs []string
+	query = `DELETE FROM team_memberships WHERE user_id = $1 RETURNING team_id`
+	if err := tx.SelectContext(ctx, &teamIDs, query, id); err != nil {
+		return nil, err
+	}
+
+	logQuery := `
+		INSERT INTO team_membership_events (user_id, action, from_team_id)
+		VALUES ($1, 'REMOVE', $2)
+	`
+	for _, teamID := range teamIDs {
+		if _, err := tx.ExecContext(ctx, logQuery, id, teamID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return teamIDs, nil
+}
+
+func (r *userRepository) List(ctx context.Context, filter model.UserFilter) ([]model.User, int, error) {
+	where := ` WHERE u.deleted_at IS NULL`
+	args := []interface{}{}
+
+	if filter.TeamID != "" {
+		args = append(args, filter.TeamID)
+		where += fmt.Sprintf(` AND EXISTS (
+			SELECT 1 FROM team_memberships tm WHERE tm.user_id = u.id AND tm.team_id = $%d
+		)`, len(args))
+	}
+
+	if filter.IsActive != nil {
+		args = append(args, *filter.IsActive)
+		where += fmt.Sprintf(` AND u.is_active = $%d`, len(args))
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users u` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := userSelect + where + fmt.Sprintf(` ORDER BY u.user_id LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	var users []model.User
+	if err := r.db.SelectContext(ctx, &users, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	if users == nil {
+		users = []model.User{}
+	}
+
+	return users, total, nil
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"github.com/jmoiron/sqlx"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) (bool, error)
+
+	Publish(ctx context.Context, eventType, actor string, data []byte) (int64, error)
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.ClaimedEventDelivery, error)
+	RecordAttempt(ctx context.Context, deliveryID, status string, responseStatus int, lastError string, retryAfter time.Duration) error
+	Requeue(ctx context.Context, deliveryID string) (bool, error)
+
+	GetDelivery(ctx context.Context, deliveryID string) (*model.EventDelivery, error)
+	ListDeliveries(ctx context.Context, filter model.EventDeliveryFilter) ([]model.EventDelivery, int, error)
+}
+
+type webhookRepository struct {
+	db *tracedDB
+}
+
+func NewWebhookRepository(db *sqlx.DB, queryTimeout time.Duration) WebhookRepository {
+	return &webhookRepository{db: newTracedDB(db, queryTimeout)}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	return r.db.GetContext(ctx, sub, query, sub.URL, sub.Secret, sub.Events)
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	var subs []model.WebhookSubscription
+	query := `SELECT id, url, secret, events, created_at FROM webhook_subscriptions ORDER BY created_at, id`
+	if err := r.db.SelectContext(ctx, &subs, query); err != nil {
+		return nil, err
+	}
+
+	if subs == nil {
+		subs = []model.WebhookSubscription{}
+	}
+
+	return subs, nil
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом её доставок.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Publish сохраняет событие и ставит его доставку каждому подходящему
+// подписчику одним запросом; без подписчиков событие не сохраняется.
+// Возвращает число поставленных доставок.
+func (r *webhookRepository) Publish(ctx context.Context, eventType, actor string, data []byte) (int64, error) {
+	query := `
+		WITH event AS (
+			INSERT INTO webhook_events (event_type, actor, data)
+			SELECT $1::text, NULLIF($2::text, ''), $3::jsonb
+			WHERE EXISTS (
+				SELECT 1 FROM webhook_subscriptions
+				WHERE cardinality(events) = 0 OR $1::text = ANY(events)
+			)
+			RETURNING id
+		)
+		INSERT INTO webhook_deliveries (event_id, subscription_id)
+		SELECT event.id, s.id
+		FROM event, webhook_subscriptions s
+		WHERE cardinality(s.events) = 0 OR $1::text = ANY(s.events)
+	`
+	result, err := r.db.ExecContext(ctx, query, eventType, actor, string(data))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimDue забирает доставки, время которых подошло, и откладывает их
+// следующую попытку на lease: если воркер упадёт, не записав результат,
+// доставку повторит другой экземпляр. SKIP LOCKED не даёт двум экземплярам
+// взять одну доставку.
+func (r *webhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.ClaimedEventDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM webhook_events e, webhook_subscriptions s
+		WHERE d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'PENDING' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		AND e.id = d.event_id AND s.id = d.subscription_id
+		RETURNING d.id, d.attempts, s.url, s.secret,
+		          e.id AS event_id, e.event_type, COALESCE(e.actor, '') AS actor,
+		          e.data, e.created_at AS occurred_at
+	`
+	var deliveries []model.ClaimedEventDelivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt записывает результат попытки; для PENDING следующая
+// попытка назначается через retryAfter.
+func (r *webhookRepository) RecordAttempt(ctx context.Context, deliveryID, status string, responseStatus int, lastError string, retryAfter time.Duration) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+		    attempts = attempts + 1,
+		    last_attempt_at = NOW(),
+		    response_status = NULLIF($3, 0),
+		    last_error = NULLIF($4, ''),
+		    next_attempt_at = NOW() + make_interval(secs => $5),
+		    delivered_at = CASE WHEN $2 = 'DELIVERED' THEN NOW() END
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, deliveryID, status, responseStatus, lastError, retryAfter.Seconds())
+	return err
+}
+
+// Requeue ставит доставку в очередь заново с полным числом попыток.
+func (r *webhookRepository) Requeue(ctx context.Context, deliveryID string) (bool, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE id = $1
+	`
+	result, err := r.db.ExecContext(ctx, query, deliveryID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+const eventDeliverySelect = `
+	SELECT d.id, d.subscription_id, d.event_id, e.event_type, d.status, d.attempts,
+	       CASE WHEN d.status = 'PENDING' THEN d.next_attempt_at END AS next_attempt_at,
+	       d.last_attempt_at, d.response_status, COALESCE(d.last_error, '') AS last_error,
+	       d.delivered_at, d.created_at
+	FROM webhook_deliveries d
+	JOIN webhook_events e ON e.id = d.event_id
+`
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, deliveryID string) (*model.EventDelivery, error) {
+	var delivery model.EventDelivery
+	if err := r.db.GetContext(ctx, &delivery, eventDeliverySelect+`WHERE d.id = $1`, deliveryID); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter model.EventDeliveryFilter) ([]model.EventDelivery, int, error) {
+	where := ` WHERE TRUE`
+	args := []interface{}{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(condition, len(args))
+	}
+
+	if filter.SubscriptionID != "" {
+		addCondition(` AND d.subscription_id = $%d`, filter.SubscriptionID)
+	}
+	if filter.EventType != "" {
+		addCondition(` AND e.event_type = $%d`, filter.EventType)
+	}
+	if filter.Status != "" {
+		addCondition(` AND d.status = $%d`, filter.Status)
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM webhook_deliveries d JOIN webhook_events e ON e.id = d.event_id` + where
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := eventDeliverySelect + where + fmt.Sprintf(`
+		ORDER BY d.created_at DESC, d.id
+		LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	var deliveries []model.EventDelivery
+	if err := r.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, 0, err
+	}
+
+	if deliveries == nil {
+		deliveries = []model.EventDelivery{}
+	}
+
+	return deliveries, total, nil
+}
@@ -0,0 +1,72 @@
+package requestctx
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/model"
+)
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	claimedActorKey
+	requestIDKey
+	loggerKey
+	principalKey
+)
+
+// WithActor сохраняет в контексте идентификатор пользователя, выполняющего запрос.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+func Actor(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
+
+// WithClaimedActor сохраняет инициатора, названного клиентом в X-Actor-ID.
+// Он не проверяется и пишется в аудит только для справки.
+func WithClaimedActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, claimedActorKey, actor)
+}
+
+func ClaimedActor(ctx context.Context) string {
+	actor, _ := ctx.Value(claimedActorKey).(string)
+	return actor
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// WithLogger сохраняет логгер запроса, уже дополненный его идентификатором.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// Logger возвращает логгер запроса, а вне HTTP-запроса — fallback.
+func Logger(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// WithPrincipal сохраняет владельца токена, которым аутентифицирован запрос.
+func WithPrincipal(ctx context.Context, principal *model.Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// Principal возвращает nil, если запрос не аутентифицирован.
+func Principal(ctx context.Context) *model.Principal {
+	principal, _ := ctx.Value(principalKey).(*model.Principal)
+	return principal
+}
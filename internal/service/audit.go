@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+)
+
+const (
+	AuditActionTeamCreate            = "team.create"
+	AuditActionTeamUpdate            = "team.update"
+	AuditActionTeamSetIsArchived     = "team.set_is_archived"
+	AuditActionTeamDelete            = "team.delete"
+	AuditActionTeamAddMember         = "team.add_member"
+	AuditActionTeamRemoveMember      = "team.remove_member"
+	AuditActionTeamMoveMember        = "team.move_member"
+	AuditActionTeamSetMemberIsActive = "team.set_member_is_active"
+	AuditActionUserSetIsActive       = "user.set_is_active"
+	AuditActionUserSetPrimaryTeam    = "user.set_primary_team"
+	AuditActionUserUpdate            = "user.update"
+	AuditActionUserDelete            = "user.delete"
+	AuditActionUserGrantRole         = "user.grant_role"
+	AuditActionUserRevokeRole        = "user.revoke_role"
+	AuditActionPRCreate              = "pr.create"
+	AuditActionPRMerge               = "pr.merge"
+	AuditActionPRClose               = "pr.close"
+	AuditActionPRReopen              = "pr.reopen"
+	AuditActionPRReassign            = "pr.reassign"
+	AuditActionPRReview              = "pr.review"
+	AuditActionTokenIssue            = "token.issue"
+	AuditActionTokenRevoke           = "token.revoke"
+	AuditActionForgeUserMap          = "forge_user.map"
+	AuditActionForgeUserUnmap        = "forge_user.unmap"
+)
+
+const (
+	auditEntityTeam        = "team"
+	auditEntityUser        = "user"
+	auditEntityPullRequest = "pull_request"
+	auditEntityAPIToken    = "api_token"
+	auditEntityForgeUser   = "forge_user"
+)
+
+type AuditService interface {
+	ListEvents(ctx context.Context, filter model.AuditFilter) (*model.AuditEventList, error)
+}
+
+type auditService struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+}
+
+func NewAuditService(repos *repository.Repositories, logger *zap.Logger) AuditService {
+	return &auditService{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+func (s *auditService) ListEvents(ctx context.Context, filter model.AuditFilter) (*model.AuditEventList, error) {
+	ctx, span := tracing.Start(ctx, "AuditService.ListEvents")
+	defer span.End()
+
+	events, total, err := s.repos.Audit.List(ctx, filter)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list audit events", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.AuditEventList{
+		Events: events,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+// auditRecorder пишет в журнал аудита изменения, сделанные сервисами.
+// Ошибка записи не откатывает уже выполненную операцию, а только логируется.
+type auditRecorder struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+}
+
+func newAuditRecorder(repos *repository.Repositories, logger *zap.Logger) *auditRecorder {
+	return &auditRecorder{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+func (a *auditRecorder) record(ctx context.Context, action, entityType, entityID string, before, after interface{}) {
+	event := &model.AuditEvent{
+		Actor:        requestctx.Actor(ctx),
+		ClaimedActor: requestctx.ClaimedActor(ctx),
+		Action:       action,
+		EntityType:   entityType,
+		EntityID:     entityID,
+		Before:       a.snapshot(ctx, before),
+		After:        a.snapshot(ctx, after),
+		RequestID:    requestctx.RequestID(ctx),
+	}
+
+	if err := a.repos.Audit.Create(ctx, event); err != nil {
+		logFor(ctx, a.logger).Error("Failed to write audit event",
+			zap.String("action", action),
+			zap.String("entity_id", entityID),
+			zap.Error(err),
+		)
+	}
+}
+
+func (a *auditRecorder) snapshot(ctx context.Context, state interface{}) json.RawMessage {
+	// Типизированный nil (например, *model.Team из snapshotTeam) — тоже
+	// отсутствие состояния, а не JSON null.
+	if state == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(state); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		logFor(ctx, a.logger).Warn("Failed to marshal audit state", zap.Error(err))
+		return nil
+	}
+
+	return data
+}
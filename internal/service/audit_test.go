@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestAudit_RecordsMutations(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	userService := NewUserService(repos, logger)
+	auditService := NewAuditService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+
+	ctx := requestctx.WithActor(context.Background(), "admin")
+	ctx = requestctx.WithClaimedActor(ctx, "alice")
+	ctx = requestctx.WithRequestID(ctx, "req-1")
+
+	if _, err := userService.SetIsActive(ctx, "u1", false); err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+
+	list, err := auditService.ListEvents(context.Background(), model.AuditFilter{
+		EntityType: "user",
+		EntityID:   "u1",
+		Limit:      10,
+	})
+	if err != nil {
+		t.Fatalf("Failed to list audit events: %v", err)
+	}
+
+	if list.Total != 1 {
+		t.Fatalf("Expected 1 audit event, got %d", list.Total)
+	}
+
+	event := list.Events[0]
+	if event.Action != AuditActionUserSetIsActive {
+		t.Errorf("Expected action '%s', got '%s'", AuditActionUserSetIsActive, event.Action)
+	}
+
+	if event.Actor != "admin" || event.RequestID != "req-1" {
+		t.Errorf("Expected actor 'admin' and request 'req-1', got '%s' and '%s'", event.Actor, event.RequestID)
+	}
+	if event.ClaimedActor != "alice" {
+		t.Errorf("Expected claimed actor 'alice', got '%s'", event.ClaimedActor)
+	}
+}
+
+func TestAuditSnapshot_TypedNil(t *testing.T) {
+	audit := newAuditRecorder(nil, zap.NewNop())
+
+	var team *model.Team
+	if data := audit.snapshot(context.Background(), team); data != nil {
+		t.Errorf("Expected no snapshot for nil team, got %s", data)
+	}
+
+	if data := audit.snapshot(context.Background(), &model.Team{TeamName: "backend"}); data == nil {
+		t.Error("Expected snapshot for team")
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/jwtauth"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// apiTokenPrefix отличает токены сервиса от прочих секретов, например в логах
+// и сканерах утечек.
+const apiTokenPrefix = "prt_"
+
+const bootstrapTokenName = "bootstrap"
+
+// AuthOptions настраивает аутентификацию. Если она выключена, все запросы
+// считаются разрешёнными. С JWT принимаются и API-токены: ими пользуются
+// для управления сервисом.
+type AuthOptions struct {
+	Enabled        bool
+	BootstrapToken string
+	JWT            *jwtauth.Verifier
+}
+
+type AuthService interface {
+	Enabled() bool
+	Authenticate(ctx context.Context, rawToken string) (*model.Principal, error)
+	IssueToken(ctx context.Context, req *model.IssueTokenRequest) (*model.IssuedAPIToken, error)
+	ListTokens(ctx context.Context) ([]model.APIToken, error)
+	RevokeToken(ctx context.Context, tokenID string) (*model.APIToken, error)
+}
+
+type authService struct {
+	repos         *repository.Repositories
+	logger        *zap.Logger
+	audit         *auditRecorder
+	enabled       bool
+	bootstrapHash []byte
+	jwt           *jwtauth.Verifier
+}
+
+func NewAuthService(repos *repository.Repositories, logger *zap.Logger, opts AuthOptions) AuthService {
+	s := &authService{
+		repos:   repos,
+		logger:  logger,
+		audit:   newAuditRecorder(repos, logger),
+		enabled: opts.Enabled,
+		jwt:     opts.JWT,
+	}
+	if opts.BootstrapToken != "" {
+		hash := sha256.Sum256([]byte(opts.BootstrapToken))
+		s.bootstrapHash = hash[:]
+	}
+	return s
+}
+
+func (s *authService) Enabled() bool {
+	return s.enabled
+}
+
+// Authenticate находит владельца токена. Токен из конфигурации даёт права
+// администратора и нужен, чтобы выпустить первые токены.
+func (s *authService) Authenticate(ctx context.Context, rawToken string) (*model.Principal, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+
+	if s.jwt != nil && strings.Count(rawToken, ".") == 2 {
+		return s.authenticateJWT(ctx, rawToken)
+	}
+
+	hash := sha256.Sum256([]byte(rawToken))
+
+	if s.bootstrapHash != nil && subtle.ConstantTimeCompare(hash[:], s.bootstrapHash) == 1 {
+		return &model.Principal{
+			TokenID: bootstrapTokenName,
+			Name:    bootstrapTokenName,
+			Scopes:  []string{model.ScopeAdmin},
+		}, nil
+	}
+
+	token, err := s.repos.APIToken.GetActiveByHash(ctx, hex.EncodeToString(hash[:]))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUnauthorized("invalid, expired or revoked token")
+		}
+		logFor(ctx, s.logger).Error("Failed to get API token", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.repos.APIToken.TouchLastUsed(ctx, token.ID); err != nil {
+		logFor(ctx, s.logger).Warn("Failed to update token last use", zap.Error(err))
+	}
+
+	principal := &model.Principal{
+		TokenID: token.ID,
+		Name:    token.Name,
+		Scopes:  token.Scopes,
+	}
+	if token.UserID != nil {
+		principal.UserID = *token.UserID
+	}
+	return principal, nil
+}
+
+// authenticateJWT принимает JWT, только если его пользователь есть в сервисе:
+// от его имени выполняются запросы и проверяются роли.
+func (s *authService) authenticateJWT(ctx context.Context, rawToken string) (*model.Principal, error) {
+	claims, err := s.jwt.Verify(ctx, rawToken)
+	if err != nil {
+		if stderrors.Is(err, jwtauth.ErrInvalidToken) {
+			return nil, errors.ErrUnauthorized(err.Error())
+		}
+		logFor(ctx, s.logger).Error("Failed to verify JWT", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if _, err := s.repos.User.GetIDByUserID(ctx, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUnauthorized("token user is not registered")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.Principal{
+		TokenID: claims.ID,
+		Name:    claims.Subject,
+		Scopes:  claims.Scopes,
+		UserID:  claims.UserID,
+	}, nil
+}
+
+func (s *authService) IssueToken(ctx context.Context, req *model.IssueTokenRequest) (*model.IssuedAPIToken, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.IssueToken")
+	defer span.End()
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, errors.ErrBadRequest("expires_at must be in the future")
+	}
+
+	if req.UserID != "" {
+		if _, err := s.repos.User.GetIDByUserID(ctx, req.UserID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.ErrNotFound("user")
+			}
+			logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+	}
+
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		logFor(ctx, s.logger).Error("Failed to generate API token", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	raw := apiTokenPrefix + hex.EncodeToString(secret[:])
+	hash := sha256.Sum256([]byte(raw))
+
+	token := model.APIToken{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.UserID != "" {
+		token.UserID = &req.UserID
+	}
+	if err := s.repos.APIToken.Create(ctx, &token, hex.EncodeToString(hash[:])); err != nil {
+		logFor(ctx, s.logger).Error("Failed to create API token", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	s.audit.record(ctx, AuditActionTokenIssue, auditEntityAPIToken, token.ID, nil, token)
+
+	return &model.IssuedAPIToken{APIToken: token, Token: raw}, nil
+}
+
+func (s *authService) ListTokens(ctx context.Context) ([]model.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ListTokens")
+	defer span.End()
+
+	tokens, err := s.repos.APIToken.List(ctx)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list API tokens", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return tokens, nil
+}
+
+func (s *authService) RevokeToken(ctx context.Context, tokenID string) (*model.APIToken, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.RevokeToken")
+	defer span.End()
+
+	token, err := s.repos.APIToken.Revoke(ctx, tokenID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("active token")
+		}
+		logFor(ctx, s.logger).Error("Failed to revoke API token", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	s.audit.record(ctx, AuditActionTokenRevoke, auditEntityAPIToken, token.ID, nil, token)
+
+	return token, nil
+}
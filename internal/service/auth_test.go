@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestAuth_IssueAuthenticateRevoke(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewAuthService(repos, logger, AuthOptions{Enabled: true, BootstrapToken: "bootstrap-secret"})
+	ctx := context.Background()
+
+	principal, err := service.Authenticate(ctx, "bootstrap-secret")
+	if err != nil || !principal.HasScope(model.ScopeAdmin) {
+		t.Fatalf("Expected bootstrap token to be admin, got principal=%v err=%v", principal, err)
+	}
+
+	issued, err := service.IssueToken(ctx, &model.IssueTokenRequest{
+		Name:   "ci",
+		Scopes: []string{model.ScopePRsWrite},
+	})
+	if err != nil {
+		t.Fatalf("Failed to issue token: %v", err)
+	}
+
+	var stored string
+	if err := db.Get(&stored, "SELECT token_hash FROM api_tokens WHERE id = $1", issued.ID); err != nil {
+		t.Fatalf("Failed to read token hash: %v", err)
+	}
+	if stored == issued.Token {
+		t.Error("Expected token to be stored hashed")
+	}
+
+	principal, err = service.Authenticate(ctx, issued.Token)
+	if err != nil {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+	if !principal.HasScope(model.ScopePRsWrite) || principal.HasScope(model.ScopeTeamsWrite) {
+		t.Errorf("Unexpected scopes: %v", principal.Scopes)
+	}
+
+	if _, err := service.RevokeToken(ctx, issued.ID); err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	_, err = service.Authenticate(ctx, issued.Token)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUnauthorized {
+		t.Errorf("Expected UNAUTHORIZED for revoked token, got %v", err)
+	}
+
+	_, err = service.RevokeToken(ctx, issued.ID)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+		t.Errorf("Expected NOT_FOUND on repeated revoke, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+)
+
+// authorizer проверяет роли пользователя, от имени которого выполняется
+// запрос. Запросы без аутентификации (AUTH_MODE=none, планировщик) не
+// проверяются, токен с областью admin действует как администратор организации.
+type authorizer struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+}
+
+func newAuthorizer(repos *repository.Repositories, logger *zap.Logger) *authorizer {
+	return &authorizer{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// subject возвращает user_id инициатора; пустая строка без ошибки означает,
+// что ограничений нет.
+func (a *authorizer) subject(ctx context.Context) (string, error) {
+	principal := requestctx.Principal(ctx)
+	if principal == nil || principal.HasScope(model.ScopeAdmin) {
+		return "", nil
+	}
+	if principal.UserID == "" {
+		return "", errors.ErrForbidden("token is not bound to a user")
+	}
+
+	isAdmin, err := a.hasRole(ctx, principal.UserID, model.RoleOrgAdmin, nil)
+	if err != nil {
+		return "", err
+	}
+	if isAdmin {
+		return "", nil
+	}
+
+	return principal.UserID, nil
+}
+
+func (a *authorizer) hasRole(ctx context.Context, userID, role string, teamID *string) (bool, error) {
+	ok, err := a.repos.Role.HasRole(ctx, userID, role, teamID)
+	if err != nil {
+		logFor(ctx, a.logger).Error("Failed to check user role", zap.Error(err))
+		return false, errors.ErrInternal(err)
+	}
+	return ok, nil
+}
+
+// requireAdmin пропускает только администратора организации.
+func (a *authorizer) requireAdmin(ctx context.Context) error {
+	userID, err := a.subject(ctx)
+	if err != nil || userID == "" {
+		return err
+	}
+	return errors.ErrForbidden("only an organization admin can do this")
+}
+
+// requireTeamLead пропускает лида команды teamID и администратора; если
+// команды нет, то только администратора.
+func (a *authorizer) requireTeamLead(ctx context.Context, teamID, teamName string) error {
+	if teamID == "" {
+		return a.requireAdmin(ctx)
+	}
+
+	userID, err := a.subject(ctx)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	isLead, err := a.hasRole(ctx, userID, model.RoleTeamLead, &teamID)
+	if err != nil {
+		return err
+	}
+	if !isLead {
+		return errors.ErrForbidden(fmt.Sprintf("only a lead of team '%s' or an admin can do this", teamName))
+	}
+	return nil
+}
+
+// requireLeadOfAll пропускает лида каждой из команд пользователя и
+// администратора; пользователя без команд меняет только администратор.
+func (a *authorizer) requireLeadOfAll(ctx context.Context, teams []model.TeamMembership) error {
+	if len(teams) == 0 {
+		return a.requireAdmin(ctx)
+	}
+	for _, team := range teams {
+		if err := a.requireTeamLead(ctx, team.TeamID, team.TeamName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireMerger пропускает к merge и закрытию PR его автора, ревьювера, бота
+// и администратора.
+func (a *authorizer) requireMerger(ctx context.Context, pr *model.PullRequest) error {
+	userID, err := a.subject(ctx)
+	if err != nil || userID == "" {
+		return err
+	}
+
+	if userID == pr.AuthorID {
+		return nil
+	}
+	for _, reviewer := range pr.AssignedReviewers {
+		if userID == reviewer {
+			return nil
+		}
+	}
+
+	isBot, err := a.hasRole(ctx, userID, model.RoleBot, nil)
+	if err != nil {
+		return err
+	}
+	if !isBot {
+		return errors.ErrForbidden("only the author, a reviewer or a bot can merge, close or reopen the pull request")
+	}
+	return nil
+}
+
+// requireReviewer пропускает решение по ревью только от самого ревьювера,
+// бота и администратора.
+func (a *authorizer) requireReviewer(ctx context.Context, reviewerID string) error {
+	userID, err := a.subject(ctx)
+	if err != nil || userID == "" || userID == reviewerID {
+		return err
+	}
+
+	isBot, err := a.hasRole(ctx, userID, model.RoleBot, nil)
+	if err != nil {
+		return err
+	}
+	if !isBot {
+		return errors.ErrForbidden("only the reviewer or a bot can submit this review")
+	}
+	return nil
+}
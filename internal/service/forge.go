@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// ForgeOptions — секреты webhook'ов внешних систем; пустой секрет выключает
+// приём событий от системы.
+type ForgeOptions struct {
+	GitHubSecret string
+	GitLabToken  string
+}
+
+// ForgeService переводит события внешних систем разработки в операции с PR
+// и хранит сопоставление их логинов с пользователями сервиса.
+type ForgeService interface {
+	MapUser(ctx context.Context, req *model.MapForgeUserRequest) (*model.ForgeUserMapping, error)
+	UnmapUser(ctx context.Context, forge, login string) error
+	ListUserMappings(ctx context.Context, forge string) ([]model.ForgeUserMapping, error)
+	HandleGitHubEvent(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookResult, error)
+	HandleGitLabEvent(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookResult, error)
+}
+
+type forgeService struct {
+	repos        *repository.Repositories
+	logger       *zap.Logger
+	audit        *auditRecorder
+	authz        *authorizer
+	pullRequests *pullRequestService
+	githubSecret string
+	gitlabToken  string
+}
+
+func NewForgeService(repos *repository.Repositories, logger *zap.Logger, opts ForgeOptions) ForgeService {
+	return &forgeService{
+		repos:        repos,
+		logger:       logger,
+		audit:        newAuditRecorder(repos, logger),
+		authz:        newAuthorizer(repos, logger),
+		pullRequests: newPullRequestService(repos, logger),
+		githubSecret: opts.GitHubSecret,
+		gitlabToken:  opts.GitLabToken,
+	}
+}
+
+func (s *forgeService) MapUser(ctx context.Context, req *model.MapForgeUserRequest) (*model.ForgeUserMapping, error) {
+	ctx, span := tracing.Start(ctx, "ForgeService.MapUser")
+	defer span.End()
+
+	if err := s.authz.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	login := normalizeLogin(req.Login)
+
+	userInternalID, err := s.repos.User.GetIDByUserID(ctx, req.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	before, err := s.getMapping(ctx, req.Forge, login)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.ForgeUser.Upsert(ctx, req.Forge, login, req.ExternalID, userInternalID); err != nil {
+		logFor(ctx, s.logger).Error("Failed to map forge user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	mapping, err := s.getMapping(ctx, req.Forge, login)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, AuditActionForgeUserMap, auditEntityForgeUser, req.Forge+":"+login, before, mapping)
+
+	return mapping, nil
+}
+
+func (s *forgeService) UnmapUser(ctx context.Context, forge, login string) error {
+	ctx, span := tracing.Start(ctx, "ForgeService.UnmapUser")
+	defer span.End()
+
+	if err := s.authz.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	login = normalizeLogin(login)
+
+	before, err := s.getMapping(ctx, forge, login)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := s.repos.ForgeUser.Delete(ctx, forge, login)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to unmap forge user", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+	if !deleted {
+		return errors.ErrNotFound("forge user mapping")
+	}
+
+	s.audit.record(ctx, AuditActionForgeUserUnmap, auditEntityForgeUser, forge+":"+login, before, nil)
+
+	return nil
+}
+
+func (s *forgeService) ListUserMappings(ctx context.Context, forge string) ([]model.ForgeUserMapping, error) {
+	ctx, span := tracing.Start(ctx, "ForgeService.ListUserMappings")
+	defer span.End()
+
+	mappings, err := s.repos.ForgeUser.List(ctx, forge)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list forge user mappings", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return mappings, nil
+}
+
+// getMapping возвращает nil, если логин не сопоставлен.
+func (s *forgeService) getMapping(ctx context.Context, forge, login string) (*model.ForgeUserMapping, error) {
+	mapping, err := s.repos.ForgeUser.Get(ctx, forge, normalizeLogin(login))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		logFor(ctx, s.logger).Error("Failed to get forge user mapping", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return mapping, nil
+}
+
+// getMappingByExternalID возвращает nil, если числовой id не сопоставлен.
+func (s *forgeService) getMappingByExternalID(ctx context.Context, forge string, externalID int64) (*model.ForgeUserMapping, error) {
+	mapping, err := s.repos.ForgeUser.GetByExternalID(ctx, forge, externalID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		logFor(ctx, s.logger).Error("Failed to get forge user mapping", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return mapping, nil
+}
+
+// withForgeActor делает инициатором изменений пользователя, сопоставленного
+// с логином отправителя события, а без сопоставления — сам логин.
+func (s *forgeService) withForgeActor(ctx context.Context, forge, login string) (context.Context, error) {
+	mapping, err := s.getMapping(ctx, forge, login)
+	if err != nil {
+		return nil, err
+	}
+	if mapping != nil {
+		return requestctx.WithActor(ctx, mapping.UserID), nil
+	}
+	return requestctx.WithActor(ctx, forge+":"+normalizeLogin(login)), nil
+}
+
+// openPR создаёт PR по событию внешней системы, находя автора по логину.
+func (s *forgeService) openPR(ctx context.Context, forge, prID, title, authorLogin string) (*model.WebhookResult, error) {
+	author, err := s.getMapping(ctx, forge, authorLogin)
+	if err != nil {
+		return nil, err
+	}
+	return s.createPR(ctx, prID, title, author, authorLogin)
+}
+
+// createPR создаёт PR от имени сопоставленного автора; authorRef называет
+// автора в причине пропуска, если сопоставления нет. Повторная доставка
+// события для уже созданного PR не считается ошибкой.
+func (s *forgeService) createPR(ctx context.Context, prID, title string, author *model.ForgeUserMapping, authorRef string) (*model.WebhookResult, error) {
+	if author == nil {
+		return ignored(fmt.Sprintf("author %q is not mapped to a user", authorRef)), nil
+	}
+
+	_, err := s.pullRequests.CreatePR(ctx, &model.CreatePRRequest{
+		PullRequestID:   prID,
+		PullRequestName: title,
+		AuthorID:        author.UserID,
+	})
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodePRExists {
+		return ignored("pull request already exists"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return processed("create", prID), nil
+}
+
+// finishPR мёржит или закрывает PR; неизвестный PR пропускается: он мог
+// быть открыт до подключения webhook'а.
+func (s *forgeService) finishPR(ctx context.Context, prID string, merged bool) (*model.WebhookResult, error) {
+	var err error
+	action := "close"
+	if merged {
+		action = "merge"
+		_, err = s.pullRequests.MergePR(ctx, prID, 0)
+	} else {
+		_, err = s.pullRequests.ClosePR(ctx, prID, 0)
+	}
+
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeNotFound {
+		return ignored("unknown pull request"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return processed(action, prID), nil
+}
+
+// reopenPR возвращает закрытый PR в работу, а неизвестный создаёт через open.
+func (s *forgeService) reopenPR(ctx context.Context, prID string, open func() (*model.WebhookResult, error)) (*model.WebhookResult, error) {
+	_, err := s.pullRequests.ReopenPR(ctx, prID, 0)
+	if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeNotFound {
+		return open()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return processed("reopen", prID), nil
+}
+
+func normalizeLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+func processed(action, prID string) *model.WebhookResult {
+	return &model.WebhookResult{Status: model.WebhookStatusProcessed, Action: action, PullRequestID: prID}
+}
+
+func ignored(reason string) *model.WebhookResult {
+	return &model.WebhookResult{Status: model.WebhookStatusIgnored, Reason: reason}
+}
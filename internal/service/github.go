@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// githubPullRequestEvent — поля события pull_request, которые использует сервис.
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Draft  bool   `json:"draft"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// HandleGitHubEvent обрабатывает событие webhook'а GitHub. PR получает
+// идентификатор вида "owner/repo#42". Черновик создаётся, только когда он
+// готов к ревью; переоткрытый PR, которого ещё нет в сервисе, создаётся.
+func (s *forgeService) HandleGitHubEvent(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookResult, error) {
+	ctx, span := tracing.Start(ctx, "ForgeService.HandleGitHubEvent")
+	defer span.End()
+
+	if s.githubSecret == "" {
+		return nil, errors.ErrNotFound("github webhook")
+	}
+	if !validGitHubSignature(s.githubSecret, delivery.Signature, delivery.Body) {
+		return nil, errors.ErrUnauthorized("invalid X-Hub-Signature-256")
+	}
+
+	switch delivery.Event {
+	case "ping":
+		return &model.WebhookResult{Status: model.WebhookStatusProcessed, Action: "ping"}, nil
+	case "pull_request":
+	default:
+		return ignored(fmt.Sprintf("unsupported event %q", delivery.Event)), nil
+	}
+
+	var event githubPullRequestEvent
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		return nil, errors.ErrBadRequest(fmt.Sprintf("invalid pull_request payload: %v", err))
+	}
+	if event.Repository.FullName == "" || event.PullRequest.Number == 0 {
+		return nil, errors.ErrBadRequest("pull_request payload has no repository or number")
+	}
+
+	ctx, err := s.withForgeActor(ctx, model.ForgeGitHub, event.Sender.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	prID := fmt.Sprintf("%s#%d", event.Repository.FullName, event.PullRequest.Number)
+	logFor(ctx, s.logger).Info("GitHub pull_request event",
+		zap.String("delivery_id", delivery.DeliveryID),
+		zap.String("action", event.Action),
+		zap.String("pr_id", prID),
+	)
+
+	open := func() (*model.WebhookResult, error) {
+		if event.PullRequest.Draft {
+			return ignored("draft pull request"), nil
+		}
+		return s.openPR(ctx, model.ForgeGitHub, prID, event.PullRequest.Title, event.PullRequest.User.Login)
+	}
+
+	switch event.Action {
+	case "opened":
+		return open()
+	case "reopened":
+		return s.reopenPR(ctx, prID, open)
+	case "ready_for_review":
+		return s.openPR(ctx, model.ForgeGitHub, prID, event.PullRequest.Title, event.PullRequest.User.Login)
+	case "closed":
+		return s.finishPR(ctx, prID, event.PullRequest.Merged)
+	default:
+		return ignored(fmt.Sprintf("unsupported action %q", event.Action)), nil
+	}
+}
+
+// validGitHubSignature проверяет заголовок X-Hub-Signature-256 вида
+// "sha256=<hex HMAC тела>".
+func validGitHubSignature(secret, header string, body []byte) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
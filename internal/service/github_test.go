@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const testGitHubSecret = "It's a Secret to Everybody"
+
+// githubDelivery подписывает записанное событие из testdata/github так же,
+// как это делает GitHub.
+func githubDelivery(t *testing.T, event, fixture string) *model.WebhookDelivery {
+	body, err := os.ReadFile(filepath.Join("testdata", "github", fixture))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(testGitHubSecret))
+	mac.Write(body)
+
+	return &model.WebhookDelivery{
+		Event:      event,
+		DeliveryID: "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+		Signature:  "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		Body:       body,
+	}
+}
+
+func newTestForgeService(repos *repository.Repositories) ForgeService {
+	logger, _ := zap.NewDevelopment()
+	return NewForgeService(repos, logger, ForgeOptions{GitHubSecret: testGitHubSecret})
+}
+
+func mapTestForgeUser(t *testing.T, forge ForgeService, login, userID string) {
+	if _, err := forge.MapUser(context.Background(), &model.MapForgeUserRequest{
+		Forge: model.ForgeGitHub, Login: login, UserID: userID,
+	}); err != nil {
+		t.Fatalf("Failed to map forge user: %v", err)
+	}
+}
+
+func TestGitHubWebhook_Signature(t *testing.T) {
+	forge := newTestForgeService(nil)
+
+	delivery := githubDelivery(t, "ping", "ping.json")
+	result, err := forge.HandleGitHubEvent(context.Background(), delivery)
+	if err != nil {
+		t.Fatalf("Failed to handle ping: %v", err)
+	}
+	if result.Status != model.WebhookStatusProcessed {
+		t.Errorf("Expected ping to be processed, got %s", result.Status)
+	}
+
+	tampered := *delivery
+	tampered.Body = append([]byte(nil), delivery.Body...)
+	tampered.Body[0] = ' '
+	for name, d := range map[string]*model.WebhookDelivery{
+		"tampered body":     &tampered,
+		"missing signature": {Event: "ping", Body: delivery.Body},
+		"sha1 signature":    {Event: "ping", Signature: "sha1=" + delivery.Signature[7:], Body: delivery.Body},
+	} {
+		_, err := forge.HandleGitHubEvent(context.Background(), d)
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUnauthorized {
+			t.Errorf("%s: expected UNAUTHORIZED, got %v", name, err)
+		}
+	}
+
+	disabled := NewForgeService(nil, zap.NewNop(), ForgeOptions{})
+	_, err = disabled.HandleGitHubEvent(context.Background(), delivery)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+		t.Errorf("Expected NOT_FOUND without secret, got %v", err)
+	}
+
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "push", "ping.json"))
+	if err != nil {
+		t.Fatalf("Failed to handle push: %v", err)
+	}
+	if result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected push to be ignored, got %s", result.Status)
+	}
+}
+
+func TestGitHubWebhook_OpenAndMerge(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	forge := newTestForgeService(repos)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	mapTestForgeUser(t, forge, "alice-dev", "u1")
+	mapTestForgeUser(t, forge, "Bob-Ops", "u2")
+
+	result, err := forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_opened.json"))
+	if err != nil {
+		t.Fatalf("Failed to handle opened: %v", err)
+	}
+	if result.Status != model.WebhookStatusProcessed || result.PullRequestID != "acme/payments#42" {
+		t.Fatalf("Expected acme/payments#42 to be created, got %+v", result)
+	}
+
+	pr, err := repos.PullRequest.GetByPRID(context.Background(), "acme/payments#42")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.PullRequestName != "Add refund endpoint" || pr.Status != "OPEN" {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+
+	// Повторная доставка не создаёт PR заново
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_opened.json"))
+	if err != nil || result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected redelivery to be ignored, got %+v, %v", result, err)
+	}
+
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_opened_draft.json"))
+	if err != nil || result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected draft to be ignored, got %+v, %v", result, err)
+	}
+
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_closed_merged.json"))
+	if err != nil {
+		t.Fatalf("Failed to handle merge: %v", err)
+	}
+	if result.Action != "merge" {
+		t.Errorf("Expected merge, got %+v", result)
+	}
+
+	pr, err = repos.PullRequest.GetByPRID(context.Background(), "acme/payments#42")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.Status != "MERGED" {
+		t.Errorf("Expected status 'MERGED', got '%s'", pr.Status)
+	}
+}
+
+func TestGitHubWebhook_CloseReopenAndUnmappedAuthor(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	forge := newTestForgeService(repos)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	result, err := forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_opened.json"))
+	if err != nil || result.Status != model.WebhookStatusIgnored {
+		t.Fatalf("Expected unmapped author to be ignored, got %+v, %v", result, err)
+	}
+
+	mapTestForgeUser(t, forge, "alice-dev", "u1")
+
+	if _, err := forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_opened.json")); err != nil {
+		t.Fatalf("Failed to handle opened: %v", err)
+	}
+
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_closed.json"))
+	if err != nil {
+		t.Fatalf("Failed to handle closed: %v", err)
+	}
+	if result.Action != "close" {
+		t.Errorf("Expected close, got %+v", result)
+	}
+
+	pr, err := repos.PullRequest.GetByPRID(context.Background(), "acme/payments#42")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.Status != "CLOSED" {
+		t.Errorf("Expected status 'CLOSED', got '%s'", pr.Status)
+	}
+
+	result, err = forge.HandleGitHubEvent(context.Background(), githubDelivery(t, "pull_request", "pull_request_reopened.json"))
+	if err != nil {
+		t.Fatalf("Failed to handle reopened: %v", err)
+	}
+	if result.Action != "reopen" {
+		t.Errorf("Expected reopen, got %+v", result)
+	}
+
+	pr, err = repos.PullRequest.GetByPRID(context.Background(), "acme/payments#42")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.Status != "OPEN" {
+		t.Errorf("Expected status 'OPEN', got '%s'", pr.Status)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+const gitlabMergeRequestEvent = "Merge Request Hook"
+
+// gitlabDraftChange — изменение признака черновика; старые версии GitLab
+// присылают его как work_in_progress.
+type gitlabDraftChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+// gitlabMergeRequestPayload — поля события Merge Request Hook, которые
+// использует сервис.
+type gitlabMergeRequestPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		AuthorID       int64  `json:"author_id"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft          *gitlabDraftChange `json:"draft"`
+		WorkInProgress *gitlabDraftChange `json:"work_in_progress"`
+	} `json:"changes"`
+}
+
+func (p *gitlabMergeRequestPayload) isDraft() bool {
+	return p.ObjectAttributes.Draft || p.ObjectAttributes.WorkInProgress
+}
+
+func (p *gitlabMergeRequestPayload) draftChange() *gitlabDraftChange {
+	if p.Changes.Draft != nil {
+		return p.Changes.Draft
+	}
+	return p.Changes.WorkInProgress
+}
+
+// authorMapping находит автора MR по числовому id из object_attributes.author_id.
+// Сопоставления без числового id находятся по логину, только если событие
+// вызвал сам автор: другого логина GitLab в событии не присылает.
+func (s *forgeService) authorMapping(ctx context.Context, event *gitlabMergeRequestPayload) (*model.ForgeUserMapping, error) {
+	authorID := event.ObjectAttributes.AuthorID
+	if authorID == 0 {
+		return nil, nil
+	}
+
+	mapping, err := s.getMappingByExternalID(ctx, model.ForgeGitLab, authorID)
+	if err != nil || mapping != nil {
+		return mapping, err
+	}
+
+	if event.User.ID == authorID {
+		return s.getMapping(ctx, model.ForgeGitLab, event.User.Username)
+	}
+	return nil, nil
+}
+
+// HandleGitLabEvent обрабатывает событие Merge Request Hook. PR получает
+// идентификатор вида "group/project!42". Черновик создаётся, только когда он
+// готов к ревью; неизвестные действия подтверждаются и пишутся в лог.
+func (s *forgeService) HandleGitLabEvent(ctx context.Context, delivery *model.WebhookDelivery) (*model.WebhookResult, error) {
+	ctx, span := tracing.Start(ctx, "ForgeService.HandleGitLabEvent")
+	defer span.End()
+
+	if s.gitlabToken == "" {
+		return nil, errors.ErrNotFound("gitlab webhook")
+	}
+	if subtle.ConstantTimeCompare([]byte(delivery.Signature), []byte(s.gitlabToken)) != 1 {
+		return nil, errors.ErrUnauthorized("invalid X-Gitlab-Token")
+	}
+
+	if delivery.Event != gitlabMergeRequestEvent {
+		return ignored(fmt.Sprintf("unsupported event %q", delivery.Event)), nil
+	}
+
+	var event gitlabMergeRequestPayload
+	if err := json.Unmarshal(delivery.Body, &event); err != nil {
+		return nil, errors.ErrBadRequest(fmt.Sprintf("invalid merge request payload: %v", err))
+	}
+	if event.Project.PathWithNamespace == "" || event.ObjectAttributes.IID == 0 {
+		return nil, errors.ErrBadRequest("merge request payload has no project or iid")
+	}
+
+	ctx, err := s.withForgeActor(ctx, model.ForgeGitLab, event.User.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	prID := fmt.Sprintf("%s!%d", event.Project.PathWithNamespace, event.ObjectAttributes.IID)
+	action := event.ObjectAttributes.Action
+	logFor(ctx, s.logger).Info("GitLab merge request event",
+		zap.String("delivery_id", delivery.DeliveryID),
+		zap.String("action", action),
+		zap.String("pr_id", prID),
+	)
+
+	open := func() (*model.WebhookResult, error) {
+		if event.isDraft() {
+			return ignored("draft merge request"), nil
+		}
+		author, err := s.authorMapping(ctx, &event)
+		if err != nil {
+			return nil, err
+		}
+		return s.createPR(ctx, prID, event.ObjectAttributes.Title, author, fmt.Sprintf("id %d", event.ObjectAttributes.AuthorID))
+	}
+
+	switch action {
+	case "open":
+		return open()
+	case "reopen":
+		return s.reopenPR(ctx, prID, open)
+	case "update":
+		change := event.draftChange()
+		if change == nil || !change.Previous || change.Current {
+			return ignored("no draft change"), nil
+		}
+		return open()
+	case "merge":
+		return s.finishPR(ctx, prID, true)
+	case "close":
+		return s.finishPR(ctx, prID, false)
+	default:
+		logFor(ctx, s.logger).Info("Ignoring unsupported GitLab merge request action",
+			zap.String("delivery_id", delivery.DeliveryID),
+			zap.String("action", action),
+		)
+		return ignored(fmt.Sprintf("unsupported action %q", action)), nil
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const testGitLabToken = "gitlab-webhook-token"
+
+// gitlabDelivery возвращает записанное событие из testdata/gitlab.
+func gitlabDelivery(t *testing.T, fixture string) *model.WebhookDelivery {
+	body, err := os.ReadFile(filepath.Join("testdata", "gitlab", fixture))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	return &model.WebhookDelivery{
+		Event:      gitlabMergeRequestEvent,
+		DeliveryID: "0b1c7a4e-5f0e-4b8e-9d51-2c1f3a8e7d10",
+		Signature:  testGitLabToken,
+		Body:       body,
+	}
+}
+
+func newTestGitLabService(repos *repository.Repositories) ForgeService {
+	logger, _ := zap.NewDevelopment()
+	return NewForgeService(repos, logger, ForgeOptions{GitLabToken: testGitLabToken})
+}
+
+func handleGitLab(t *testing.T, forge ForgeService, fixture string) *model.WebhookResult {
+	result, err := forge.HandleGitLabEvent(context.Background(), gitlabDelivery(t, fixture))
+	if err != nil {
+		t.Fatalf("Failed to handle %s: %v", fixture, err)
+	}
+	return result
+}
+
+func mapTestGitLabUser(t *testing.T, forge ForgeService, login string, externalID int64, userID string) {
+	if _, err := forge.MapUser(context.Background(), &model.MapForgeUserRequest{
+		Forge: model.ForgeGitLab, Login: login, ExternalID: &externalID, UserID: userID,
+	}); err != nil {
+		t.Fatalf("Failed to map forge user: %v", err)
+	}
+}
+
+func TestGitLabWebhook_Token(t *testing.T) {
+	forge := newTestGitLabService(nil)
+
+	delivery := gitlabDelivery(t, "merge_request_open.json")
+	delivery.Signature = "wrong"
+	_, err := forge.HandleGitLabEvent(context.Background(), delivery)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUnauthorized {
+		t.Errorf("Expected UNAUTHORIZED for wrong token, got %v", err)
+	}
+
+	delivery.Signature = ""
+	_, err = forge.HandleGitLabEvent(context.Background(), delivery)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUnauthorized {
+		t.Errorf("Expected UNAUTHORIZED without token, got %v", err)
+	}
+
+	disabled := NewForgeService(nil, zap.NewNop(), ForgeOptions{})
+	_, err = disabled.HandleGitLabEvent(context.Background(), gitlabDelivery(t, "merge_request_open.json"))
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeNotFound {
+		t.Errorf("Expected NOT_FOUND without token, got %v", err)
+	}
+
+	push := gitlabDelivery(t, "merge_request_open.json")
+	push.Event = "Push Hook"
+	result, err := forge.HandleGitLabEvent(context.Background(), push)
+	if err != nil {
+		t.Fatalf("Failed to handle push: %v", err)
+	}
+	if result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected push to be ignored, got %s", result.Status)
+	}
+}
+
+func TestGitLabWebhook_Lifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	forge := newTestGitLabService(repos)
+
+	createTestTeam(t, repos, "billing", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	mapTestGitLabUser(t, forge, "Alice.Smith", 4012, "u1")
+	mapTestGitLabUser(t, forge, "bob.jones", 4077, "u2")
+
+	const prID = "acme/platform/billing!17"
+	expectStatus := func(status string) {
+		t.Helper()
+		pr, err := repos.PullRequest.GetByPRID(context.Background(), prID)
+		if err != nil {
+			t.Fatalf("Failed to get PR: %v", err)
+		}
+		if pr.Status != status {
+			t.Errorf("Expected status '%s', got '%s'", status, pr.Status)
+		}
+	}
+
+	if result := handleGitLab(t, forge, "merge_request_open_draft.json"); result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected draft to be ignored, got %+v", result)
+	}
+
+	// Черновик готов к ревью — PR создаётся
+	result := handleGitLab(t, forge, "merge_request_update_ready.json")
+	if result.Status != model.WebhookStatusProcessed || result.PullRequestID != prID {
+		t.Fatalf("Expected %s to be created, got %+v", prID, result)
+	}
+	expectStatus("OPEN")
+
+	if result := handleGitLab(t, forge, "merge_request_open.json"); result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected existing PR to be ignored, got %+v", result)
+	}
+
+	if result := handleGitLab(t, forge, "merge_request_close.json"); result.Action != "close" {
+		t.Errorf("Expected close, got %+v", result)
+	}
+	expectStatus("CLOSED")
+
+	if result := handleGitLab(t, forge, "merge_request_reopen.json"); result.Action != "reopen" {
+		t.Errorf("Expected reopen, got %+v", result)
+	}
+	expectStatus("OPEN")
+
+	if result := handleGitLab(t, forge, "merge_request_approved.json"); result.Status != model.WebhookStatusIgnored {
+		t.Errorf("Expected unknown action to be ignored, got %+v", result)
+	}
+
+	if result := handleGitLab(t, forge, "merge_request_merge.json"); result.Action != "merge" {
+		t.Errorf("Expected merge, got %+v", result)
+	}
+	expectStatus("MERGED")
+}
+
+func TestGitLabWebhook_ReopenUnknownCreates(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	forge := newTestGitLabService(repos)
+
+	createTestTeam(t, repos, "billing", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	if _, err := forge.MapUser(context.Background(), &model.MapForgeUserRequest{
+		Forge: model.ForgeGitLab, Login: "alice.smith", UserID: "u1",
+	}); err != nil {
+		t.Fatalf("Failed to map forge user: %v", err)
+	}
+
+	result := handleGitLab(t, forge, "merge_request_reopen.json")
+	if result.Action != "create" {
+		t.Errorf("Expected unknown reopened PR to be created, got %+v", result)
+	}
+
+	pr, err := repos.PullRequest.GetByPRID(context.Background(), "acme/platform/billing!17")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.Status != "OPEN" || pr.AuthorID != "u1" {
+		t.Errorf("Unexpected PR: %+v", pr)
+	}
+}
+
+func TestGitLabWebhook_AuthorByExternalID(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	forge := newTestGitLabService(repos)
+
+	createTestTeam(t, repos, "billing", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	mapTestGitLabUser(t, forge, "alice.smith", 4012, "u1")
+	mapTestGitLabUser(t, forge, "bob.jones", 4077, "u2")
+
+	// Событие вызвал ревьювер, автор известен только по author_id
+	result := handleGitLab(t, forge, "merge_request_reopen_by_reviewer.json")
+	if result.Action != "create" {
+		t.Fatalf("Expected PR to be created, got %+v", result)
+	}
+
+	pr, err := repos.PullRequest.GetByPRID(context.Background(), "acme/platform/billing!17")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+	if pr.AuthorID != "u1" {
+		t.Errorf("Expected author u1, got %s", pr.AuthorID)
+	}
+
+	// Переименованный в GitLab логин забирает числовой id у прежнего
+	mapTestGitLabUser(t, forge, "alice.s", 4012, "u1")
+	mappings, err := forge.ListUserMappings(context.Background(), model.ForgeGitLab)
+	if err != nil {
+		t.Fatalf("Failed to list mappings: %v", err)
+	}
+	for _, m := range mappings {
+		if m.Login == "alice.smith" {
+			t.Errorf("Expected stale mapping to be removed, got %+v", m)
+		}
+	}
+}
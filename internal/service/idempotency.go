@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, owner, key, route, requestHash string) (*model.IdempotencyRecord, error)
+	Complete(ctx context.Context, owner, key, route string, statusCode int, headers model.Headers, body []byte) error
+	Release(ctx context.Context, owner, key, route string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyService struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+	ttl    time.Duration
+}
+
+func NewIdempotencyService(repos *repository.Repositories, logger *zap.Logger, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{
+		repos:  repos,
+		logger: logger,
+		ttl:    ttl,
+	}
+}
+
+// Begin занимает ключ владельца owner за запросом и возвращает nil, если
+// запрос нужно выполнить. Ключи разных владельцев не пересекаются. Для уже выполненного запроса с тем же телом возвращается
+// сохранённый ответ.
+func (s *idempotencyService) Begin(ctx context.Context, owner, key, route, requestHash string) (*model.IdempotencyRecord, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+
+	reserved, err := s.repos.Idempotency.Reserve(ctx, owner, key, route, requestHash, s.ttl)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to reserve idempotency key", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repos.Idempotency.Get(ctx, owner, key, route)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Запись удалили между Reserve и Get: первый запрос завершился ошибкой.
+			return nil, errors.ErrIdempotencyInUse()
+		}
+		logFor(ctx, s.logger).Error("Failed to get idempotency key", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, errors.ErrIdempotencyMismatch()
+	}
+	if record.StatusCode == nil {
+		return nil, errors.ErrIdempotencyInUse()
+	}
+
+	return record, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, owner, key, route string, statusCode int, headers model.Headers, body []byte) error {
+	if err := s.repos.Idempotency.Complete(ctx, owner, key, route, statusCode, headers, body); err != nil {
+		logFor(ctx, s.logger).Error("Failed to store idempotent response", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+	return nil
+}
+
+// Release освобождает ключ, чтобы запрос можно было повторить.
+func (s *idempotencyService) Release(ctx context.Context, owner, key, route string) error {
+	if err := s.repos.Idempotency.Delete(ctx, owner, key, route); err != nil {
+		logFor(ctx, s.logger).Error("Failed to release idempotency key", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+	return nil
+}
+
+func (s *idempotencyService) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.repos.Idempotency.DeleteExpired(ctx)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to delete expired idempotency keys", zap.Error(err))
+		return 0, errors.ErrInternal(err)
+	}
+	return deleted, nil
+}
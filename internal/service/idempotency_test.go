@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestIdempotency_ReplayAndMismatch(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewIdempotencyService(repos, logger, time.Hour)
+	ctx := context.Background()
+
+	record, err := service.Begin(ctx, "user:u1", "key-1", "/team/add", "hash-a")
+	if err != nil || record != nil {
+		t.Fatalf("Expected key to be reserved, got record=%v err=%v", record, err)
+	}
+
+	// Первый запрос ещё выполняется
+	_, err = service.Begin(ctx, "user:u1", "key-1", "/team/add", "hash-a")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeIdempotencyInUse {
+		t.Fatalf("Expected IDEMPOTENCY_KEY_IN_USE, got %v", err)
+	}
+
+	if err := service.Complete(ctx, "user:u1", "key-1", "/team/add", 201, model.Headers{"ETag": `"1"`}, []byte(`{"team":{}}`)); err != nil {
+		t.Fatalf("Failed to complete: %v", err)
+	}
+
+	record, err = service.Begin(ctx, "user:u1", "key-1", "/team/add", "hash-a")
+	if err != nil || record == nil {
+		t.Fatalf("Expected stored response, got record=%v err=%v", record, err)
+	}
+	if *record.StatusCode != 201 || string(record.ResponseBody) != `{"team":{}}` {
+		t.Errorf("Unexpected stored response: %d %s", *record.StatusCode, record.ResponseBody)
+	}
+	if record.ResponseHeaders["ETag"] != `"1"` {
+		t.Errorf("Expected stored ETag, got %v", record.ResponseHeaders)
+	}
+
+	_, err = service.Begin(ctx, "user:u1", "key-1", "/team/add", "hash-b")
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeIdempotencyMismatch {
+		t.Errorf("Expected IDEMPOTENCY_KEY_MISMATCH, got %v", err)
+	}
+
+	// Тот же ключ на другом маршруте не связан с первым
+	record, err = service.Begin(ctx, "user:u1", "key-1", "/pullRequest/reassign", "hash-b")
+	if err != nil || record != nil {
+		t.Errorf("Expected key to be reserved for another route, got record=%v err=%v", record, err)
+	}
+
+	// Тот же ключ и то же тело у другого пользователя — свой запрос, а не чужой ответ
+	record, err = service.Begin(ctx, "user:u2", "key-1", "/team/add", "hash-a")
+	if err != nil || record != nil {
+		t.Errorf("Expected key to be reserved for another owner, got record=%v err=%v", record, err)
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+import "assign-reviewers-for-pull-requests/internal/metrics"
+
+// Доменные метрики сервиса, отдаются через /metrics.
+var (
+	pullRequestsCreated = metrics.Default.NewCounterVec(
+		"pull_requests_created_total", "Pull requests created.")
+	pullRequestsMerged = metrics.Default.NewCounterVec(
+		"pull_requests_merged_total", "Pull requests merged (repeated merges are not counted).")
+	reviewerReassignments = metrics.Default.NewCounterVec(
+		"reviewer_reassignments_total", "Reviewers replaced on open pull requests, by reason.", "reason")
+	noCandidateFailures = metrics.Default.NewCounterVec(
+		"reviewer_no_candidate_total", "Operations that failed because no reviewer candidate was available.", "operation")
+	webhookDeliveryAttempts = metrics.Default.NewCounterVec(
+		"webhook_delivery_attempts_total", "Outbound webhook delivery attempts, by resulting delivery status.", "status")
+)
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"math/rand"
 	"time"
@@ -9,73 +10,93 @@ import (
 	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
 )
 
 type PullRequestService interface {
-	CreatePR(req *model.CreatePRRequest) (*model.PullRequest, error)
-	MergePR(prID string) (*model.PullRequest, error)
-	ReassignReviewer(prID, oldUserID string) (*model.PullRequest, string, error)
+	CreatePR(ctx context.Context, req *model.CreatePRRequest) (*model.PullRequest, error)
+	MergePR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error)
+	ClosePR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error)
+	ReopenPR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error)
+	ReassignReviewer(ctx context.Context, prID, oldUserID string, expectedVersion int) (*model.PullRequest, string, error)
+	GetHistory(ctx context.Context, prID string) (*model.PullRequestHistory, error)
+	SubmitReview(ctx context.Context, req *model.SubmitReviewRequest) (*model.ReviewDecision, error)
 }
 
 type pullRequestService struct {
 	repos  *repository.Repositories
 	logger *zap.Logger
+	audit  *auditRecorder
+	authz  *authorizer
+	events *eventPublisher
 	rnd    *rand.Rand
 }
 
 func NewPullRequestService(repos *repository.Repositories, logger *zap.Logger) PullRequestService {
+	return newPullRequestService(repos, logger)
+}
+
+func newPullRequestService(repos *repository.Repositories, logger *zap.Logger) *pullRequestService {
 	source := rand.NewSource(time.Now().UnixNano())
 	return &pullRequestService{
 		repos:  repos,
 		logger: logger,
+		audit:  newAuditRecorder(repos, logger),
+		authz:  newAuthorizer(repos, logger),
+		events: newEventPublisher(repos, logger),
 		rnd:    rand.New(source),
 	}
 }
 
-func (s *pullRequestService) CreatePR(req *model.CreatePRRequest) (*model.PullRequest, error) {
-	exists, err := s.repos.PullRequest.Exists(req.PullRequestID)
+func (s *pullRequestService) CreatePR(ctx context.Context, req *model.CreatePRRequest) (*model.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.CreatePR")
+	defer span.End()
+
+	exists, err := s.repos.PullRequest.Exists(ctx, req.PullRequestID)
 	if err != nil {
-		s.logger.Error("Failed to check PR existence", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to check PR existence", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 	if exists {
 		return nil, errors.ErrPRExists(req.PullRequestID)
 	}
 
-	author, err := s.repos.User.GetByUserID(req.AuthorID)
+	author, err := s.repos.User.GetByUserID(ctx, req.AuthorID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.ErrNotFound("author")
 		}
-		s.logger.Error("Failed to get author", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to get author", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
-	if author.TeamID == "" {
-		return nil, errors.ErrNotFound("author team")
-	}
-
 	if !author.IsActive {
 		return nil, errors.ErrNotFound("author is inactive")
 	}
 
-	prInternalID, err := s.repos.PullRequest.Create(req.PullRequestID, req.PullRequestName, author.ID)
+	team, err := s.resolvePRTeam(ctx, author, req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	prInternalID, err := s.repos.PullRequest.Create(ctx, req.PullRequestID, req.PullRequestName, author.ID, team.TeamID)
 	if err != nil {
-		s.logger.Error("Failed to create PR", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to create PR", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
-	reviewers, err := s.selectReviewersWithBalancing(author.TeamID, []string{author.ID}, 2)
+	reviewers, err := s.selectReviewersWithBalancing(ctx, team.TeamID, []string{author.ID}, 2)
 	if err != nil {
-		s.logger.Error("Failed to select reviewers", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to select reviewers", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	if len(reviewers) == 0 {
-		s.logger.Warn("No reviewers available for PR",
+		logFor(ctx, s.logger).Warn("No reviewers available for PR",
 			zap.String("pr_id", req.PullRequestID),
-			zap.String("team_id", author.TeamID),
+			zap.String("team_id", team.TeamID),
 		)
+		noCandidateFailures.Inc("create")
 		return nil, errors.ErrNoCandidate()
 	}
 
@@ -84,13 +105,13 @@ func (s *pullRequestService) CreatePR(req *model.CreatePRRequest) (*model.PullRe
 		reviewerIDs[i] = reviewer.ID
 	}
 
-	if err := s.repos.PullRequest.AssignReviewersBatch(prInternalID, reviewerIDs); err != nil {
-		s.logger.Error("Failed to assign reviewers", zap.Error(err))
+	if err := s.repos.PullRequest.AssignReviewersBatch(ctx, prInternalID, reviewerIDs, model.ReviewerActionCreate); err != nil {
+		logFor(ctx, s.logger).Error("Failed to assign reviewers", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	for _, reviewer := range reviewers {
-		_ = s.repos.Stats.RecordAssignment(reviewer.ID, prInternalID)
+		_ = s.repos.Stats.RecordAssignment(ctx, reviewer.ID, prInternalID)
 	}
 
 	reviewerUserIDs := make([]string, len(reviewers))
@@ -103,12 +124,23 @@ func (s *pullRequestService) CreatePR(req *model.CreatePRRequest) (*model.PullRe
 		PullRequestID:     req.PullRequestID,
 		PullRequestName:   req.PullRequestName,
 		AuthorID:          author.UserID,
+		TeamID:            team.TeamID,
+		TeamName:          team.TeamName,
 		Status:            "OPEN",
+		Version:           1,
 		CreatedAt:         time.Now(),
 		AssignedReviewers: reviewerUserIDs,
 	}
 
-	s.logger.Info("PR created successfully",
+	s.audit.record(ctx, AuditActionPRCreate, auditEntityPullRequest, pr.PullRequestID, nil, pr)
+	pullRequestsCreated.Inc()
+
+	s.events.publish(ctx, model.EventPRCreated, model.PullRequestEventData{PullRequest: pr})
+	for _, reviewerID := range reviewerUserIDs {
+		s.events.reviewerAssigned(ctx, pr, reviewerID, model.ReviewerActionCreate)
+	}
+
+	logFor(ctx, s.logger).Info("PR created successfully",
 		zap.String("pr_id", req.PullRequestID),
 		zap.Strings("reviewers", reviewerUserIDs),
 	)
@@ -116,50 +148,191 @@ func (s *pullRequestService) CreatePR(req *model.CreatePRRequest) (*model.PullRe
 	return pr, nil
 }
 
-func (s *pullRequestService) MergePR(prID string) (*model.PullRequest, error) {
-	pr, err := s.repos.PullRequest.GetByPRID(prID)
+// MergePR и ReassignReviewer принимают ожидаемую версию PR (0 — без
+// проверки). Даже без неё изменение применяется только к той версии,
+// которую прочитал сервис, поэтому параллельные запросы не затирают друг друга.
+func (s *pullRequestService) MergePR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.MergePR")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, prID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.ErrNotFound("pull request")
 		}
-		s.logger.Error("Failed to get PR", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
+	if err := s.authz.requireMerger(ctx, pr); err != nil {
+		return nil, err
+	}
+
 	if pr.Status == "MERGED" {
-		s.logger.Info("PR already merged", zap.String("pr_id", prID))
+		logFor(ctx, s.logger).Info("PR already merged", zap.String("pr_id", prID))
 		return pr, nil
 	}
+	if pr.Status == "CLOSED" {
+		return nil, errors.ErrPRClosed()
+	}
+
+	if err := checkVersion(pr, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	before := *pr
 
 	mergedAt := time.Now()
-	if err := s.repos.PullRequest.UpdateStatus(pr.ID, "MERGED", &mergedAt); err != nil {
-		s.logger.Error("Failed to update PR status", zap.Error(err))
+	version, err := s.repos.PullRequest.UpdateStatus(ctx, pr.ID, "MERGED", &mergedAt, pr.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrVersionConflict()
+		}
+		logFor(ctx, s.logger).Error("Failed to update PR status", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	pr.Status = "MERGED"
 	pr.MergedAt = sql.NullTime{Time: mergedAt, Valid: true}
+	pr.Version = version
+
+	s.audit.record(ctx, AuditActionPRMerge, auditEntityPullRequest, pr.PullRequestID, before, pr)
+	pullRequestsMerged.Inc()
+	s.events.publish(ctx, model.EventPRMerged, model.PullRequestEventData{PullRequest: pr})
+
+	logFor(ctx, s.logger).Info("PR merged successfully", zap.String("pr_id", prID))
+
+	return pr, nil
+}
+
+// ClosePR закрывает PR без merge; ревьюверы остаются в истории, но PR
+// больше не учитывается в их открытых ревью.
+func (s *pullRequestService) ClosePR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ClosePR")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, prID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("pull request")
+		}
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.authz.requireMerger(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	if pr.Status == "CLOSED" {
+		logFor(ctx, s.logger).Info("PR already closed", zap.String("pr_id", prID))
+		return pr, nil
+	}
+	if pr.Status == "MERGED" {
+		return nil, errors.ErrPRMerged()
+	}
+
+	if err := checkVersion(pr, expectedVersion); err != nil {
+		return nil, err
+	}
 
-	s.logger.Info("PR merged successfully", zap.String("pr_id", prID))
+	before := *pr
+
+	version, err := s.repos.PullRequest.UpdateStatus(ctx, pr.ID, "CLOSED", nil, pr.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrVersionConflict()
+		}
+		logFor(ctx, s.logger).Error("Failed to update PR status", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	pr.Status = "CLOSED"
+	pr.Version = version
+
+	s.audit.record(ctx, AuditActionPRClose, auditEntityPullRequest, pr.PullRequestID, before, pr)
+
+	logFor(ctx, s.logger).Info("PR closed", zap.String("pr_id", prID))
 
 	return pr, nil
 }
 
-func (s *pullRequestService) ReassignReviewer(prID, oldUserID string) (*model.PullRequest, string, error) {
-	pr, err := s.repos.PullRequest.GetByPRID(prID)
+// ReopenPR возвращает закрытый PR в работу с прежними ревьюверами.
+func (s *pullRequestService) ReopenPR(ctx context.Context, prID string, expectedVersion int) (*model.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ReopenPR")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, prID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("pull request")
+		}
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.authz.requireMerger(ctx, pr); err != nil {
+		return nil, err
+	}
+
+	if pr.Status == "OPEN" {
+		logFor(ctx, s.logger).Info("PR already open", zap.String("pr_id", prID))
+		return pr, nil
+	}
+	if pr.Status == "MERGED" {
+		return nil, errors.ErrPRMerged()
+	}
+
+	if err := checkVersion(pr, expectedVersion); err != nil {
+		return nil, err
+	}
+
+	before := *pr
+
+	version, err := s.repos.PullRequest.UpdateStatus(ctx, pr.ID, "OPEN", nil, pr.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrVersionConflict()
+		}
+		logFor(ctx, s.logger).Error("Failed to update PR status", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	pr.Status = "OPEN"
+	pr.Version = version
+
+	s.audit.record(ctx, AuditActionPRReopen, auditEntityPullRequest, pr.PullRequestID, before, pr)
+
+	logFor(ctx, s.logger).Info("PR reopened", zap.String("pr_id", prID))
+
+	return pr, nil
+}
+
+func (s *pullRequestService) ReassignReviewer(ctx context.Context, prID, oldUserID string, expectedVersion int) (*model.PullRequest, string, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.ReassignReviewer")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, prID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, "", errors.ErrNotFound("pull request")
 		}
-		s.logger.Error("Failed to get PR", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
 		return nil, "", errors.ErrInternal(err)
 	}
 
 	if pr.Status == "MERGED" {
 		return nil, "", errors.ErrPRMerged()
 	}
+	if pr.Status == "CLOSED" {
+		return nil, "", errors.ErrPRClosed()
+	}
+
+	if err := checkVersion(pr, expectedVersion); err != nil {
+		return nil, "", err
+	}
 
-	oldUser, err := s.repos.User.GetByUserID(oldUserID)
+	oldUser, err := s.repos.User.GetByUserID(ctx, oldUserID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, "", errors.ErrNotFound("user")
@@ -167,16 +340,16 @@ func (s *pullRequestService) ReassignReviewer(prID, oldUserID string) (*model.Pu
 		return nil, "", errors.ErrInternal(err)
 	}
 
-	isAssigned, err := s.repos.PullRequest.IsReviewerAssigned(pr.ID, oldUser.ID)
+	isAssigned, err := s.repos.PullRequest.IsReviewerAssigned(ctx, pr.ID, oldUser.ID)
 	if err != nil {
-		s.logger.Error("Failed to check reviewer assignment", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to check reviewer assignment", zap.Error(err))
 		return nil, "", errors.ErrInternal(err)
 	}
 	if !isAssigned {
 		return nil, "", errors.ErrNotAssigned()
 	}
 
-	author, err := s.repos.User.GetByUserID(pr.AuthorID)
+	author, err := s.repos.User.GetByUserID(ctx, pr.AuthorID)
 	if err != nil {
 		return nil, "", errors.ErrInternal(err)
 	}
@@ -184,36 +357,48 @@ func (s *pullRequestService) ReassignReviewer(prID, oldUserID string) (*model.Pu
 	currentReviewerIDs := []string{author.ID, oldUser.ID}
 	for _, rUserID := range pr.AssignedReviewers {
 		if rUserID != oldUserID {
-			u, _ := s.repos.User.GetByUserID(rUserID)
+			u, err := s.repos.User.GetByUserID(ctx, rUserID)
+			if err != nil && err != sql.ErrNoRows {
+				logFor(ctx, s.logger).Error("Failed to get reviewer", zap.Error(err))
+				return nil, "", errors.ErrInternal(err)
+			}
 			if u != nil {
 				currentReviewerIDs = append(currentReviewerIDs, u.ID)
 			}
 		}
 	}
 
-	newReviewers, err := s.selectReviewers(author.TeamID, currentReviewerIDs, 1)
+	if pr.TeamID == "" {
+		noCandidateFailures.Inc("reassign")
+		return nil, "", errors.ErrNoCandidate()
+	}
+
+	newReviewers, err := s.selectReviewers(ctx, pr.TeamID, currentReviewerIDs, 1)
 	if err != nil {
-		s.logger.Error("Failed to select new reviewer", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to select new reviewer", zap.Error(err))
 		return nil, "", errors.ErrInternal(err)
 	}
 
 	if len(newReviewers) == 0 {
+		noCandidateFailures.Inc("reassign")
 		return nil, "", errors.ErrNoCandidate()
 	}
 
 	newReviewer := newReviewers[0]
 
-	if err := s.repos.PullRequest.RemoveReviewer(pr.ID, oldUser.ID); err != nil {
-		s.logger.Error("Failed to remove old reviewer", zap.Error(err))
+	version, err := s.repos.PullRequest.ReplaceReviewer(ctx, pr.ID, oldUser.ID, newReviewer.ID, model.ReviewerActionReassign, pr.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", errors.ErrVersionConflict()
+		}
+		logFor(ctx, s.logger).Error("Failed to replace reviewer", zap.Error(err))
 		return nil, "", errors.ErrInternal(err)
 	}
 
-	if err := s.repos.PullRequest.AssignReviewer(pr.ID, newReviewer.ID); err != nil {
-		s.logger.Error("Failed to assign new reviewer", zap.Error(err))
-		return nil, "", errors.ErrInternal(err)
-	}
+	_ = s.repos.Stats.RecordAssignment(ctx, newReviewer.ID, pr.ID)
 
-	_ = s.repos.Stats.RecordAssignment(newReviewer.ID, pr.ID)
+	before := *pr
+	before.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
 
 	for i, rUserID := range pr.AssignedReviewers {
 		if rUserID == oldUserID {
@@ -221,8 +406,13 @@ func (s *pullRequestService) ReassignReviewer(prID, oldUserID string) (*model.Pu
 			break
 		}
 	}
+	pr.Version = version
 
-	s.logger.Info("Reviewer reassigned successfully",
+	s.audit.record(ctx, AuditActionPRReassign, auditEntityPullRequest, pr.PullRequestID, before, pr)
+	reviewerReassignments.Inc(model.ReviewerActionReassign)
+	s.events.reviewerReassigned(ctx, pr, oldUserID, newReviewer.UserID, model.ReviewerActionReassign)
+
+	logFor(ctx, s.logger).Info("Reviewer reassigned successfully",
 		zap.String("pr_id", prID),
 		zap.String("old_reviewer", oldUserID),
 		zap.String("new_reviewer", newReviewer.UserID),
@@ -231,36 +421,357 @@ func (s *pullRequestService) ReassignReviewer(prID, oldUserID string) (*model.Pu
 	return pr, newReviewer.UserID, nil
 }
 
-func (s *pullRequestService) selectReviewers(teamID string, excludeInternalIDs []string, count int) ([]model.User, error) {
-	activeUsers, err := s.repos.User.GetActiveByTeamID(teamID, excludeInternalIDs)
+func (s *pullRequestService) SubmitReview(ctx context.Context, req *model.SubmitReviewRequest) (*model.ReviewDecision, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.SubmitReview")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, req.PullRequestID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("pull request")
+		}
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.authz.requireReviewer(ctx, req.ReviewerID); err != nil {
 		return nil, err
 	}
 
-	if len(activeUsers) <= count {
-		return activeUsers, nil
+	if pr.Status == "MERGED" {
+		return nil, errors.ErrPRMerged()
+	}
+	if pr.Status == "CLOSED" {
+		return nil, errors.ErrPRClosed()
 	}
 
-	s.rnd.Shuffle(len(activeUsers), func(i, j int) {
-		activeUsers[i], activeUsers[j] = activeUsers[j], activeUsers[i]
-	})
+	if err := checkVersion(pr, req.ExpectedVersion); err != nil {
+		return nil, err
+	}
 
-	return activeUsers[:count], nil
+	reviewer, err := s.repos.User.GetByUserID(ctx, req.ReviewerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get reviewer", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	isAssigned, err := s.repos.PullRequest.IsReviewerAssigned(ctx, pr.ID, reviewer.ID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to check reviewer assignment", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	if !isAssigned {
+		return nil, errors.ErrNotAssigned()
+	}
+
+	createdAt, err := s.repos.PullRequest.AddReviewDecision(ctx, pr.ID, reviewer.ID, req.Decision)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to record review decision", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	decision := &model.ReviewDecision{
+		PullRequestID: pr.PullRequestID,
+		ReviewerID:    reviewer.UserID,
+		Decision:      req.Decision,
+		CreatedAt:     createdAt,
+	}
+
+	s.audit.record(ctx, AuditActionPRReview, auditEntityPullRequest, pr.PullRequestID, nil, decision)
+
+	logFor(ctx, s.logger).Info("Review decision recorded",
+		zap.String("pr_id", pr.PullRequestID),
+		zap.String("reviewer", reviewer.UserID),
+		zap.String("decision", req.Decision),
+	)
+
+	return decision, nil
+}
+
+func (s *pullRequestService) GetHistory(ctx context.Context, prID string) (*model.PullRequestHistory, error) {
+	ctx, span := tracing.Start(ctx, "PullRequestService.GetHistory")
+	defer span.End()
+
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, prID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("pull request")
+		}
+		logFor(ctx, s.logger).Error("Failed to get PR", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	assignments, err := s.repos.PullRequest.GetReviewerHistory(ctx, pr.ID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get reviewer history", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	hops := 0
+	for _, assignment := range assignments {
+		if assignment.AssignedAction != model.ReviewerActionCreate {
+			hops++
+		}
+	}
+
+	return &model.PullRequestHistory{
+		PullRequestID: pr.PullRequestID,
+		Hops:          hops,
+		Assignments:   assignments,
+	}, nil
+}
+
+// reassignOpenReviews снимает пользователя со всех открытых PR команды
+// fromTeamID и назначает вместо него нового ревьювера; reason попадает в историю PR.
+// Пустой fromTeamID означает PR любой команды.
+func (s *pullRequestService) reassignOpenReviews(ctx context.Context, user *model.User, fromTeamID, reason string) (int, error) {
+	prs, err := s.repos.PullRequest.GetPRsByReviewerUserID(ctx, user.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	reassigned := 0
+	for _, short := range prs {
+		if short.Status != "OPEN" {
+			continue
+		}
+
+		pr, err := s.repos.PullRequest.GetByPRID(ctx, short.PullRequestID)
+		if err != nil {
+			return reassigned, err
+		}
+
+		if fromTeamID != "" && pr.TeamID != fromTeamID {
+			continue
+		}
+
+		excludeIDs := []string{user.ID}
+		author, err := s.repos.User.GetByUserID(ctx, pr.AuthorID)
+		if err != nil && err != sql.ErrNoRows {
+			return reassigned, err
+		}
+		if author != nil {
+			excludeIDs = append(excludeIDs, author.ID)
+		}
+		for _, rUserID := range pr.AssignedReviewers {
+			if rUserID != user.UserID {
+				u, err := s.repos.User.GetByUserID(ctx, rUserID)
+				if err != nil && err != sql.ErrNoRows {
+					return reassigned, err
+				}
+				if u != nil {
+					excludeIDs = append(excludeIDs, u.ID)
+				}
+			}
+		}
+
+		before := *pr
+		before.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+		pr.AssignedReviewers = withoutReviewer(pr.AssignedReviewers, user.UserID)
+
+		var newReviewers []model.User
+		if pr.TeamID != "" {
+			newReviewers, err = s.selectReviewersWithBalancing(ctx, pr.TeamID, excludeIDs, 1)
+			if err != nil {
+				return reassigned, err
+			}
+		}
+
+		if len(newReviewers) == 0 {
+			if _, err := s.repos.PullRequest.RemoveReviewer(ctx, pr.ID, user.ID, reason, pr.Version); err != nil {
+				if err == sql.ErrNoRows {
+					// PR успели изменить или закрыть — ревьювера не трогаем
+					logFor(ctx, s.logger).Warn("PR changed during reassignment, skipping",
+						zap.String("pr_id", pr.PullRequestID),
+						zap.String("reviewer", user.UserID),
+					)
+					continue
+				}
+				return reassigned, err
+			}
+			if pr.TeamID != "" {
+				logFor(ctx, s.logger).Warn("No replacement reviewer available",
+					zap.String("pr_id", pr.PullRequestID),
+					zap.String("old_reviewer", user.UserID),
+				)
+			}
+			s.audit.record(ctx, AuditActionPRReassign, auditEntityPullRequest, pr.PullRequestID, before, pr)
+			continue
+		}
+
+		if _, err := s.repos.PullRequest.ReplaceReviewer(ctx, pr.ID, user.ID, newReviewers[0].ID, reason, pr.Version); err != nil {
+			if err == sql.ErrNoRows {
+				// PR успели изменить или закрыть — ревьювера не трогаем
+				logFor(ctx, s.logger).Warn("PR changed during reassignment, skipping",
+					zap.String("pr_id", pr.PullRequestID),
+					zap.String("reviewer", user.UserID),
+				)
+				continue
+			}
+			return reassigned, err
+		}
+		_ = s.repos.Stats.RecordAssignment(ctx, newReviewers[0].ID, pr.ID)
+		reassigned++
+		reviewerReassignments.Inc(reason)
+
+		pr.AssignedReviewers = append(pr.AssignedReviewers, newReviewers[0].UserID)
+		s.audit.record(ctx, AuditActionPRReassign, auditEntityPullRequest, pr.PullRequestID, before, pr)
+		s.events.reviewerReassigned(ctx, pr, user.UserID, newReviewers[0].UserID, reason)
+
+		logFor(ctx, s.logger).Info("Open review reassigned",
+			zap.String("pr_id", pr.PullRequestID),
+			zap.String("old_reviewer", user.UserID),
+			zap.String("new_reviewer", newReviewers[0].UserID),
+		)
+	}
+
+	return reassigned, nil
+}
+
+func withoutReviewer(reviewers []string, userID string) []string {
+	result := make([]string, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		if reviewer != userID {
+			result = append(result, reviewer)
+		}
+	}
+	return result
+}
+
+// resolvePRTeam определяет команду PR: явно указанную автором или его основную.
+func (s *pullRequestService) resolvePRTeam(ctx context.Context, author *model.User, teamName string) (*model.TeamMembership, error) {
+	if teamName == "" {
+		if author.TeamID == "" {
+			return nil, errors.ErrNotFound("author team")
+		}
+		if author.TeamArchived {
+			return nil, errors.ErrTeamArchived(author.TeamName)
+		}
+		return &model.TeamMembership{TeamID: author.TeamID, TeamName: author.TeamName, IsPrimary: true, IsActive: true}, nil
+	}
+
+	teamID, err := s.repos.Team.GetIDByName(ctx, teamName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	membership, err := s.repos.Membership.Get(ctx, author.ID, teamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotMember(author.UserID, teamName)
+		}
+		logFor(ctx, s.logger).Error("Failed to get team membership", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if membership.TeamArchived {
+		return nil, errors.ErrTeamArchived(teamName)
+	}
+
+	return membership, nil
+}
+
+func (s *pullRequestService) selectReviewers(ctx context.Context, teamID string, excludeInternalIDs []string, count int) ([]model.User, error) {
+	return s.selectFromHierarchy(ctx, teamID, excludeInternalIDs, count, s.pickRandom)
 }
 
+func (s *pullRequestService) selectReviewersWithBalancing(ctx context.Context, teamID string, excludeInternalIDs []string, count int) ([]model.User, error) {
+	return s.selectFromHierarchy(ctx, teamID, excludeInternalIDs, count, s.pickLeastLoaded)
+}
 
-func (s *pullRequestService) selectReviewersWithBalancing(teamID string, excludeInternalIDs []string, count int) ([]model.User, error) {
-	activeUsers, err := s.repos.User.GetActiveByTeamID(teamID, excludeInternalIDs)
+// selectFromHierarchy набирает ревьюверов из команды PR, а если её пул пуст
+// или перегружен (max_open_reviews), поднимается к родительским командам.
+func (s *pullRequestService) selectFromHierarchy(
+	ctx context.Context,
+	teamID string,
+	excludeInternalIDs []string,
+	count int,
+	pick func(ctx context.Context, candidates []model.User, count int) []model.User,
+) ([]model.User, error) {
+	chain, err := s.repos.Team.GetAncestors(ctx, teamID)
 	if err != nil {
 		return nil, err
 	}
 
+	exclude := append([]string{}, excludeInternalIDs...)
+	selected := []model.User{}
+
+	for _, team := range chain {
+		if len(selected) >= count {
+			break
+		}
+
+		candidates, err := s.repos.User.GetActiveByTeamID(ctx, team.ID, exclude)
+		if err != nil {
+			return nil, err
+		}
+
+		if team.MaxOpenReviews.Valid {
+			candidates = s.withoutSaturated(ctx, candidates, int(team.MaxOpenReviews.Int64))
+		}
+
+		picked := pick(ctx, candidates, count-len(selected))
+		for _, user := range picked {
+			selected = append(selected, user)
+			exclude = append(exclude, user.ID)
+		}
+
+		if len(picked) > 0 && team.Depth > 0 {
+			logFor(ctx, s.logger).Info("Reviewer selection escalated to parent team",
+				zap.String("team_id", teamID),
+				zap.String("parent_team", team.TeamName),
+				zap.Int("picked", len(picked)),
+			)
+		}
+	}
+
+	return selected, nil
+}
+
+func (s *pullRequestService) withoutSaturated(ctx context.Context, users []model.User, maxOpenReviews int) []model.User {
+	available := make([]model.User, 0, len(users))
+	for _, user := range users {
+		openReviews, err := s.repos.PullRequest.GetReviewerAssignmentCount(ctx, user.ID)
+		if err != nil {
+			logFor(ctx, s.logger).Warn("Failed to get assignment count",
+				zap.String("user_id", user.UserID),
+				zap.Error(err),
+			)
+			openReviews = 0
+		}
+		if openReviews < maxOpenReviews {
+			available = append(available, user)
+		}
+	}
+	return available
+}
+
+func (s *pullRequestService) pickRandom(ctx context.Context, activeUsers []model.User, count int) []model.User {
+	if len(activeUsers) <= count {
+		return activeUsers
+	}
+
+	s.rnd.Shuffle(len(activeUsers), func(i, j int) {
+		activeUsers[i], activeUsers[j] = activeUsers[j], activeUsers[i]
+	})
+
+	return activeUsers[:count]
+}
+
+func (s *pullRequestService) pickLeastLoaded(ctx context.Context, activeUsers []model.User, count int) []model.User {
 	if len(activeUsers) == 0 {
-		return []model.User{}, nil
+		return []model.User{}
 	}
 
 	if len(activeUsers) <= count {
-		return activeUsers, nil
+		return activeUsers
 	}
 
 	type userWithCount struct {
@@ -270,9 +781,9 @@ func (s *pullRequestService) selectReviewersWithBalancing(teamID string, exclude
 
 	usersWithCounts := make([]userWithCount, len(activeUsers))
 	for i, user := range activeUsers {
-		assignmentCount, err := s.repos.PullRequest.GetReviewerAssignmentCount(user.ID)
+		assignmentCount, err := s.repos.PullRequest.GetReviewerAssignmentCount(ctx, user.ID)
 		if err != nil {
-			s.logger.Warn("Failed to get assignment count",
+			logFor(ctx, s.logger).Warn("Failed to get assignment count",
 				zap.String("user_id", user.UserID),
 				zap.Error(err),
 			)
@@ -294,5 +805,13 @@ func (s *pullRequestService) selectReviewersWithBalancing(teamID string, exclude
 		result[i] = usersWithCounts[i].user
 	}
 
-	return result, nil
-}
\ No newline at end of file
+	return result
+}
+
+// checkVersion сверяет версию PR с ожидаемой клиентом; 0 — проверка не нужна.
+func checkVersion(pr *model.PullRequest, expectedVersion int) error {
+	if expectedVersion != 0 && pr.Version != expectedVersion {
+		return errors.ErrVersionConflict()
+	}
+	return nil
+}
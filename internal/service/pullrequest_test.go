@@ -1,7 +1,14 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
+	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
 	"github.com/jmoiron/sqlx"
@@ -24,96 +31,68 @@ func setupTestDB(t *testing.T) *sqlx.DB {
 	_, _ = db.Exec("TRUNCATE TABLE pull_requests CASCADE")
 	_, _ = db.Exec("TRUNCATE TABLE users CASCADE")
 	_, _ = db.Exec("TRUNCATE TABLE teams CASCADE")
+	_, _ = db.Exec("TRUNCATE TABLE audit_events")
+	_, _ = db.Exec("TRUNCATE TABLE idempotency_keys")
+	_, _ = db.Exec("TRUNCATE TABLE api_tokens")
+	_, _ = db.Exec("TRUNCATE TABLE user_roles")
+	_, _ = db.Exec("TRUNCATE TABLE rate_limit_buckets")
+	_, _ = db.Exec("TRUNCATE TABLE forge_user_mappings")
+	_, _ = db.Exec("TRUNCATE TABLE webhook_subscriptions, webhook_events CASCADE")
 
 	return db
 }
 
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// createSchema один раз за прогон пересоздаёт схему из migrations/*.up.sql,
+// чтобы тесты шли на той же схеме, что и сервис.
 func createSchema(t *testing.T, db *sqlx.DB) {
+	migrateOnce.Do(func() {
+		migrateErr = applyMigrations(db)
+	})
+	if migrateErr != nil {
+		t.Fatalf("Failed to create schema: %v", migrateErr)
+	}
+}
+
+func applyMigrations(db *sqlx.DB) error {
+	if _, err := db.Exec(`DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+		return err
+	}
 
-	var exists bool
-	err := db.Get(&exists, "SELECT EXISTS (SELECT FROM information_schema.tables WHERE table_name = 'teams')")
-	if err == nil && exists {
-		return 
-	}
-
-
-	schema := `
-		CREATE TABLE IF NOT EXISTS teams (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			team_name VARCHAR(255) NOT NULL UNIQUE,
-			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-		);
-
-		CREATE TABLE IF NOT EXISTS users (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			user_id VARCHAR(255) NOT NULL UNIQUE,
-			username VARCHAR(255) NOT NULL,
-			team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
-			is_active BOOLEAN NOT NULL DEFAULT TRUE,
-			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_users_team_id ON users(team_id);
-		CREATE INDEX IF NOT EXISTS idx_users_is_active ON users(is_active);
-		CREATE INDEX IF NOT EXISTS idx_users_user_id ON users(user_id);
-
-		CREATE TABLE IF NOT EXISTS pull_requests (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			pull_request_id VARCHAR(255) NOT NULL UNIQUE,
-			pull_request_name VARCHAR(500) NOT NULL,
-			author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			status VARCHAR(20) NOT NULL CHECK (status IN ('OPEN', 'MERGED')),
-			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			merged_at TIMESTAMP,
-			CONSTRAINT chk_merged_at CHECK (
-				(status = 'MERGED' AND merged_at IS NOT NULL) OR
-				(status = 'OPEN' AND merged_at IS NULL)
-			)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_pr_author_id ON pull_requests(author_id);
-		CREATE INDEX IF NOT EXISTS idx_pr_status ON pull_requests(status);
-		CREATE INDEX IF NOT EXISTS idx_pr_created_at ON pull_requests(created_at);
-		CREATE INDEX IF NOT EXISTS idx_pr_pull_request_id ON pull_requests(pull_request_id);
-
-		CREATE TABLE IF NOT EXISTS pr_reviewers (
-			pull_request_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
-			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			PRIMARY KEY (pull_request_id, user_id)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user_id ON pr_reviewers(user_id);
-
-		CREATE TABLE IF NOT EXISTS assignment_stats (
-			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-			pr_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
-			assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
-			UNIQUE(user_id, pr_id)
-		);
-
-		CREATE INDEX IF NOT EXISTS idx_stats_user_id ON assignment_stats(user_id);
-		CREATE INDEX IF NOT EXISTS idx_stats_pr_id ON assignment_stats(pr_id);
-		CREATE INDEX IF NOT EXISTS idx_stats_assigned_at ON assignment_stats(assigned_at);
-	`
-
-	_, err = db.Exec(schema)
+	files, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.up.sql"))
 	if err != nil {
-		t.Fatalf("Failed to create schema: %v", err)
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no migrations found")
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
 	}
+
+	return nil
 }
 
 func createTestTeam(t *testing.T, repos *repository.Repositories, teamName string, users []model.TeamMember) string {
-	teamID, err := repos.Team.Create(teamName)
+	teamID, err := repos.Team.Create(context.Background(), teamName)
 	if err != nil {
 		t.Fatalf("Failed to create team: %v", err)
 	}
 
 	for _, user := range users {
-		err := repos.User.Upsert(user.UserID, user.Username, teamID, user.IsActive)
+		err := repos.User.Ensure(context.Background(), user.UserID, user.Username, teamID, user.IsActive)
 		if err != nil {
 			t.Fatalf("Failed to create user: %v", err)
 		}
@@ -129,7 +108,7 @@ func TestCreatePR_Success(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -146,7 +125,7 @@ func TestCreatePR_Success(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	pr, err := service.CreatePR(req)
+	pr, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
@@ -182,7 +161,7 @@ func TestCreatePR_DuplicateID(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -198,12 +177,12 @@ func TestCreatePR_DuplicateID(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	_, err := service.CreatePR(req)
+	_, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create first PR: %v", err)
 	}
 
-	_, err = service.CreatePR(req)
+	_, err = service.CreatePR(context.Background(), req)
 	if err == nil {
 		t.Error("Expected error when creating duplicate PR")
 	}
@@ -216,7 +195,7 @@ func TestCreatePR_NoActiveReviewers(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -232,7 +211,7 @@ func TestCreatePR_NoActiveReviewers(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	_, err := service.CreatePR(req)
+	_, err := service.CreatePR(context.Background(), req)
 	if err == nil {
 		t.Error("Expected error when no active reviewers available")
 	}
@@ -245,7 +224,7 @@ func TestMergePR_Success(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -261,12 +240,12 @@ func TestMergePR_Success(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	_, err := service.CreatePR(req)
+	_, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
 
-	pr, err := service.MergePR("pr-001")
+	pr, err := service.MergePR(context.Background(), "pr-001", 0)
 	if err != nil {
 		t.Fatalf("Failed to merge PR: %v", err)
 	}
@@ -287,7 +266,7 @@ func TestMergePR_Idempotent(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -303,17 +282,17 @@ func TestMergePR_Idempotent(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	_, err := service.CreatePR(req)
+	_, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
 
-	pr1, err := service.MergePR("pr-001")
+	pr1, err := service.MergePR(context.Background(), "pr-001", 0)
 	if err != nil {
 		t.Fatalf("Failed to merge PR first time: %v", err)
 	}
 
-	pr2, err := service.MergePR("pr-001")
+	pr2, err := service.MergePR(context.Background(), "pr-001", 0)
 	if err != nil {
 		t.Fatalf("Failed to merge PR second time: %v", err)
 	}
@@ -323,6 +302,64 @@ func TestMergePR_Idempotent(t *testing.T) {
 	}
 }
 
+func TestClosePR_BlocksMerge(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewPullRequestService(repos, logger)
+
+	users := []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	}
+	createTestTeam(t, repos, "backend", users)
+
+	for _, id := range []string{"pr-001", "pr-002"} {
+		_, err := service.CreatePR(context.Background(), &model.CreatePRRequest{
+			PullRequestID:   id,
+			PullRequestName: "Test PR",
+			AuthorID:        "u1",
+		})
+		if err != nil {
+			t.Fatalf("Failed to create PR: %v", err)
+		}
+	}
+
+	pr, err := service.ClosePR(context.Background(), "pr-001", 0)
+	if err != nil {
+		t.Fatalf("Failed to close PR: %v", err)
+	}
+	if pr.Status != "CLOSED" {
+		t.Errorf("Expected status 'CLOSED', got '%s'", pr.Status)
+	}
+	if pr.MergedAt.Valid {
+		t.Error("Expected MergedAt to be empty")
+	}
+
+	// Повторное закрытие идемпотентно
+	if _, err := service.ClosePR(context.Background(), "pr-001", 0); err != nil {
+		t.Errorf("Expected repeated close to succeed, got %v", err)
+	}
+
+	_, err = service.MergePR(context.Background(), "pr-001", 0)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodePRClosed {
+		t.Errorf("Expected PR_CLOSED, got %v", err)
+	}
+
+	if _, err := service.MergePR(context.Background(), "pr-002", 0); err != nil {
+		t.Fatalf("Failed to merge PR: %v", err)
+	}
+	_, err = service.ClosePR(context.Background(), "pr-002", 0)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodePRMerged {
+		t.Errorf("Expected PR_MERGED, got %v", err)
+	}
+}
+
 func TestReassignReviewer_Success(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -330,7 +367,7 @@ func TestReassignReviewer_Success(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -348,7 +385,7 @@ func TestReassignReviewer_Success(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	pr, err := service.CreatePR(req)
+	pr, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
@@ -359,7 +396,7 @@ func TestReassignReviewer_Success(t *testing.T) {
 
 	oldReviewer := pr.AssignedReviewers[0]
 
-	newPR, replacedBy, err := service.ReassignReviewer("pr-001", oldReviewer)
+	newPR, replacedBy, err := service.ReassignReviewer(context.Background(), "pr-001", oldReviewer, 0)
 	if err != nil {
 		t.Fatalf("Failed to reassign reviewer: %v", err)
 	}
@@ -380,6 +417,119 @@ func TestReassignReviewer_Success(t *testing.T) {
 	}
 }
 
+func TestPullRequest_VersionConflict(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "David", IsActive: true},
+		{UserID: "u5", Username: "Eve", IsActive: true},
+	})
+
+	pr, err := service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+	if pr.Version != 1 {
+		t.Fatalf("Expected version 1 after create, got %d", pr.Version)
+	}
+
+	reassigned, _, err := service.ReassignReviewer(context.Background(), "pr-001", pr.AssignedReviewers[0], 1)
+	if err != nil {
+		t.Fatalf("Failed to reassign reviewer: %v", err)
+	}
+	if reassigned.Version != 2 {
+		t.Errorf("Expected version 2 after reassign, got %d", reassigned.Version)
+	}
+
+	// Клиент с устаревшей версией не должен перезаписать изменения
+	_, _, err = service.ReassignReviewer(context.Background(), "pr-001", reassigned.AssignedReviewers[1], 1)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeVersionConflict {
+		t.Errorf("Expected VERSION_CONFLICT on reassign, got %v", err)
+	}
+
+	_, err = service.MergePR(context.Background(), "pr-001", 1)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeVersionConflict {
+		t.Errorf("Expected VERSION_CONFLICT on merge, got %v", err)
+	}
+
+	merged, err := service.MergePR(context.Background(), "pr-001", 2)
+	if err != nil {
+		t.Fatalf("Failed to merge PR: %v", err)
+	}
+	if merged.Version != 3 {
+		t.Errorf("Expected version 3 after merge, got %d", merged.Version)
+	}
+}
+
+func TestGetHistory_TracksReassignment(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "David", IsActive: true},
+	})
+
+	pr, err := service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	oldReviewer := pr.AssignedReviewers[0]
+	if _, _, err := service.ReassignReviewer(context.Background(), "pr-001", oldReviewer, 0); err != nil {
+		t.Fatalf("Failed to reassign reviewer: %v", err)
+	}
+
+	history, err := service.GetHistory(context.Background(), "pr-001")
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+
+	if history.Hops != 1 {
+		t.Errorf("Expected 1 hop, got %d", history.Hops)
+	}
+
+	if len(history.Assignments) != len(pr.AssignedReviewers)+1 {
+		t.Fatalf("Expected %d assignments, got %d", len(pr.AssignedReviewers)+1, len(history.Assignments))
+	}
+
+	for _, a := range history.Assignments {
+		if a.UserID == oldReviewer {
+			if a.RemovedAt == nil || a.RemovedAction != model.ReviewerActionReassign {
+				t.Errorf("Expected old reviewer to be removed by reassign, got %+v", a)
+			}
+		}
+	}
+}
+
 func TestReassignReviewer_AfterMerge(t *testing.T) {
 	db := setupTestDB(t)
 	if db == nil {
@@ -387,7 +537,7 @@ func TestReassignReviewer_AfterMerge(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -404,12 +554,12 @@ func TestReassignReviewer_AfterMerge(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	pr, err := service.CreatePR(req)
+	pr, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
 
-	_, err = service.MergePR("pr-001")
+	_, err = service.MergePR(context.Background(), "pr-001", 0)
 	if err != nil {
 		t.Fatalf("Failed to merge PR: %v", err)
 	}
@@ -418,7 +568,7 @@ func TestReassignReviewer_AfterMerge(t *testing.T) {
 		t.Fatal("No reviewers assigned")
 	}
 
-	_, _, err = service.ReassignReviewer("pr-001", pr.AssignedReviewers[0])
+	_, _, err = service.ReassignReviewer(context.Background(), "pr-001", pr.AssignedReviewers[0], 0)
 	if err == nil {
 		t.Error("Expected error when reassigning after merge")
 	}
@@ -431,7 +581,7 @@ func TestReassignReviewer_NotAssigned(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewPullRequestService(repos, logger)
 
@@ -448,14 +598,120 @@ func TestReassignReviewer_NotAssigned(t *testing.T) {
 		AuthorID:        "u1",
 	}
 
-	_, err := service.CreatePR(req)
+	_, err := service.CreatePR(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Failed to create PR: %v", err)
 	}
 
 
-	_, _, err = service.ReassignReviewer("pr-001", "u1")
+	_, _, err = service.ReassignReviewer(context.Background(), "pr-001", "u1", 0)
 	if err == nil {
 		t.Error("Expected error when reassigning user not assigned as reviewer")
 	}
-}
\ No newline at end of file
+}
+func TestCreatePR_ExplicitTeam(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	platformID := createTestTeam(t, repos, "platform", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u4", Username: "David", IsActive: true},
+	})
+
+	authorID, err := repos.User.GetIDByUserID(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("Failed to get author id: %v", err)
+	}
+	if err := repos.Membership.Add(context.Background(), authorID, platformID, false, true); err != nil {
+		t.Fatalf("Failed to add membership: %v", err)
+	}
+
+	pr, err := service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+		TeamName:        "platform",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if pr.TeamName != "platform" {
+		t.Errorf("Expected team 'platform', got '%s'", pr.TeamName)
+	}
+
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "u3" {
+		t.Errorf("Expected reviewers [u3], got %v", pr.AssignedReviewers)
+	}
+
+	pr, err = service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-002",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR in primary team: %v", err)
+	}
+
+	if pr.TeamName != "backend" {
+		t.Errorf("Expected primary team 'backend', got '%s'", pr.TeamName)
+	}
+
+	_, err = service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-003",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+		TeamName:        "frontend",
+	})
+	if err == nil {
+		t.Error("Expected error when author is not a member of the team")
+	}
+}
+
+func TestCreatePR_EscalatesToParentTeam(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewPullRequestService(repos, logger)
+
+	parentID := createTestTeam(t, repos, "department", []model.TeamMember{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	squadID := createTestTeam(t, repos, "squad", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+	if err := repos.Team.SetParent(context.Background(), squadID, &parentID); err != nil {
+		t.Fatalf("Failed to set parent team: %v", err)
+	}
+
+	pr, err := service.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if len(pr.AssignedReviewers) != 1 || pr.AssignedReviewers[0] != "u2" {
+		t.Errorf("Expected reviewer from parent team [u2], got %v", pr.AssignedReviewers)
+	}
+}
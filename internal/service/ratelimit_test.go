@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"assign-reviewers-for-pull-requests/internal/ratelimit"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+)
+
+func TestStoreLimiter_SharedBucket(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	// Два лимитера на одной БД — как две реплики
+	first := ratelimit.NewStoreLimiter(repos.RateLimit)
+	second := ratelimit.NewStoreLimiter(repos.RateLimit)
+	ctx := context.Background()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Hour}
+
+	for _, limiter := range []ratelimit.Limiter{first, second} {
+		result, err := limiter.Allow(ctx, "token:1|*", limit)
+		if err != nil || !result.Allowed {
+			t.Fatalf("Expected request to be allowed, got %+v err=%v", result, err)
+		}
+	}
+
+	result, err := first.Allow(ctx, "token:1|*", limit)
+	if err != nil {
+		t.Fatalf("Failed to check limit: %v", err)
+	}
+	if result.Allowed || result.RetryAfter <= 0 {
+		t.Errorf("Expected third request across replicas to be rejected, got %+v", result)
+	}
+
+	deleted, err := first.Cleanup(ctx, 0)
+	if err != nil || deleted != 1 {
+		t.Errorf("Expected bucket to be deleted, got %d err=%v", deleted, err)
+	}
+}
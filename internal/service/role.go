@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+type RoleService interface {
+	GrantRole(ctx context.Context, req *model.RoleRequest) (*model.UserRoles, error)
+	RevokeRole(ctx context.Context, req *model.RoleRequest) (*model.UserRoles, error)
+	ListRoles(ctx context.Context, userID string) (*model.UserRoles, error)
+}
+
+type roleService struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+	audit  *auditRecorder
+	authz  *authorizer
+}
+
+func NewRoleService(repos *repository.Repositories, logger *zap.Logger) RoleService {
+	return &roleService{
+		repos:  repos,
+		logger: logger,
+		audit:  newAuditRecorder(repos, logger),
+		authz:  newAuthorizer(repos, logger),
+	}
+}
+
+func (s *roleService) GrantRole(ctx context.Context, req *model.RoleRequest) (*model.UserRoles, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.GrantRole")
+	defer span.End()
+
+	if err := s.authz.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userInternalID, teamID, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.list(ctx, req.UserID, userInternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := s.repos.Role.Grant(ctx, userInternalID, req.Role, teamID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to grant role", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	if !granted {
+		return before, nil
+	}
+
+	return s.recordChange(ctx, AuditActionUserGrantRole, req.UserID, userInternalID, before)
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, req *model.RoleRequest) (*model.UserRoles, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.RevokeRole")
+	defer span.End()
+
+	if err := s.authz.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userInternalID, teamID, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := s.list(ctx, req.UserID, userInternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.repos.Role.Revoke(ctx, userInternalID, req.Role, teamID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to revoke role", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	if !revoked {
+		return nil, errors.ErrNotFound("role")
+	}
+
+	return s.recordChange(ctx, AuditActionUserRevokeRole, req.UserID, userInternalID, before)
+}
+
+func (s *roleService) ListRoles(ctx context.Context, userID string) (*model.UserRoles, error) {
+	ctx, span := tracing.Start(ctx, "RoleService.ListRoles")
+	defer span.End()
+
+	userInternalID, err := s.getUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.list(ctx, userID, userInternalID)
+}
+
+// resolve проверяет, что команда указана только для командных ролей, и
+// возвращает внутренние идентификаторы пользователя и команды.
+func (s *roleService) resolve(ctx context.Context, req *model.RoleRequest) (string, *string, error) {
+	teamRole := req.Role == model.RoleTeamLead || req.Role == model.RoleMember
+	if teamRole && req.TeamName == "" {
+		return "", nil, errors.ErrBadRequest(fmt.Sprintf("team_name is required for role %s", req.Role))
+	}
+	if !teamRole && req.TeamName != "" {
+		return "", nil, errors.ErrBadRequest(fmt.Sprintf("role %s is not bound to a team", req.Role))
+	}
+
+	userInternalID, err := s.getUserID(ctx, req.UserID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !teamRole {
+		return userInternalID, nil, nil
+	}
+
+	teamID, err := s.repos.Team.GetIDByName(ctx, req.TeamName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+		return "", nil, errors.ErrInternal(err)
+	}
+
+	return userInternalID, &teamID, nil
+}
+
+func (s *roleService) getUserID(ctx context.Context, userID string) (string, error) {
+	id, err := s.repos.User.GetIDByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return "", errors.ErrInternal(err)
+	}
+	return id, nil
+}
+
+func (s *roleService) list(ctx context.Context, userID, userInternalID string) (*model.UserRoles, error) {
+	roles, err := s.repos.Role.ListByUser(ctx, userInternalID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list user roles", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return &model.UserRoles{UserID: userID, Roles: roles}, nil
+}
+
+func (s *roleService) recordChange(ctx context.Context, action, userID, userInternalID string, before *model.UserRoles) (*model.UserRoles, error) {
+	after, err := s.list(ctx, userID, userInternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, action, auditEntityUser, userID, before, after)
+
+	logFor(ctx, s.logger).Info("User roles updated",
+		zap.String("user_id", userID),
+		zap.String("action", action),
+	)
+
+	return after, nil
+}
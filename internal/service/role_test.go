@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func asUser(userID string) context.Context {
+	return requestctx.WithPrincipal(context.Background(), &model.Principal{
+		TokenID: "token-" + userID,
+		Name:    userID,
+		Scopes:  []string{model.ScopeUsersWrite, model.ScopeTeamsWrite, model.ScopePRsWrite},
+		UserID:  userID,
+	})
+}
+
+func TestRoles_TeamLeadDeactivatesOnlyOwnTeam(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	roles := NewRoleService(repos, logger)
+	teams := NewTeamService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+
+	if _, err := roles.GrantRole(context.Background(), &model.RoleRequest{
+		UserID: "u1", Role: model.RoleTeamLead, TeamName: "backend",
+	}); err != nil {
+		t.Fatalf("Failed to grant role: %v", err)
+	}
+
+	// Без роли лида выдать роль нельзя
+	_, err := roles.GrantRole(asUser("u2"), &model.RoleRequest{UserID: "u2", Role: model.RoleOrgAdmin})
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for grant by member, got %v", err)
+	}
+
+	_, err = teams.SetMemberIsActive(asUser("u2"), "backend", "u1", false)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for member, got %v", err)
+	}
+
+	if _, err := teams.SetMemberIsActive(asUser("u1"), "backend", "u2", false); err != nil {
+		t.Errorf("Expected lead to deactivate own team member, got %v", err)
+	}
+
+	_, err = teams.SetMemberIsActive(asUser("u1"), "frontend", "u3", false)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for another team, got %v", err)
+	}
+}
+
+func TestRoles_TeamLeadCannotChangeAnotherTeam(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	roles := NewRoleService(repos, logger)
+	teams := NewTeamService(repos, logger)
+	users := NewUserService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+
+	if _, err := roles.GrantRole(context.Background(), &model.RoleRequest{
+		UserID: "u1", Role: model.RoleTeamLead, TeamName: "backend",
+	}); err != nil {
+		t.Fatalf("Failed to grant role: %v", err)
+	}
+
+	lead := asUser("u1")
+	name := "Chuck"
+	calls := map[string]func() error{
+		"AddMember": func() error {
+			_, err := teams.AddMember(lead, &model.AddTeamMemberRequest{
+				TeamName: "frontend", UserID: "u4", Username: "Dave", IsActive: true,
+			})
+			return err
+		},
+		"RemoveMember": func() error {
+			_, err := teams.RemoveMember(lead, "frontend", "u3")
+			return err
+		},
+		"MoveMember": func() error {
+			_, err := teams.MoveMember(lead, "u2", "backend", "frontend")
+			return err
+		},
+		"UpdateTeam": func() error {
+			_, err := teams.UpdateTeam(lead, &model.UpdateTeamRequest{TeamName: "frontend", NewTeamName: "web"})
+			return err
+		},
+		"SetIsArchived": func() error {
+			_, err := teams.SetIsArchived(lead, "frontend", true)
+			return err
+		},
+		"DeleteTeam": func() error {
+			return teams.DeleteTeam(lead, "frontend", "")
+		},
+		"UpdateUser": func() error {
+			_, err := users.UpdateUser(lead, &model.UpdateUserRequest{UserID: "u3", Username: &name})
+			return err
+		},
+		"DeleteUser": func() error {
+			return users.DeleteUser(lead, "u3")
+		},
+		"SetPrimaryTeam": func() error {
+			_, err := users.SetPrimaryTeam(lead, "u3", "frontend")
+			return err
+		},
+		"CreateTeam": func() error {
+			_, err := teams.CreateTeam(lead, &model.Team{TeamName: "web", ParentTeamName: "frontend"})
+			return err
+		},
+		"CreateRootTeam": func() error {
+			_, err := teams.CreateTeam(lead, &model.Team{TeamName: "platform"})
+			return err
+		},
+	}
+
+	for op, call := range calls {
+		err := call()
+		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+			t.Errorf("%s: expected FORBIDDEN for another team, got %v", op, err)
+		}
+	}
+
+	if _, err := users.UpdateUser(lead, &model.UpdateUserRequest{UserID: "u2", Username: &name}); err != nil {
+		t.Errorf("Expected lead to update own team member, got %v", err)
+	}
+
+	if _, err := teams.CreateTeam(lead, &model.Team{TeamName: "payments", ParentTeamName: "backend"}); err != nil {
+		t.Errorf("Expected lead to create sub-team, got %v", err)
+	}
+
+	// u2 состоит и в frontend: деактивировать его целиком лид backend не может
+	if _, err := teams.AddMember(context.Background(), &model.AddTeamMemberRequest{
+		TeamName: "frontend", UserID: "u2", Username: "Bob", IsActive: true,
+	}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	_, err := users.SetIsActive(lead, "u2", false)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for user in another team, got %v", err)
+	}
+}
+
+func TestRoles_MergeRestrictedToAuthorReviewerOrBot(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	roles := NewRoleService(repos, logger)
+	prs := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "bot", Username: "CI", IsActive: false},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+
+	if _, err := roles.GrantRole(context.Background(), &model.RoleRequest{UserID: "bot", Role: model.RoleBot}); err != nil {
+		t.Fatalf("Failed to grant role: %v", err)
+	}
+
+	for _, id := range []string{"pr-001", "pr-002"} {
+		if _, err := prs.CreatePR(context.Background(), &model.CreatePRRequest{
+			PullRequestID: id, PullRequestName: "Test PR", AuthorID: "u1",
+		}); err != nil {
+			t.Fatalf("Failed to create PR: %v", err)
+		}
+	}
+
+	_, err := prs.MergePR(asUser("u3"), "pr-001", 0)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for outsider, got %v", err)
+	}
+
+	// u2 — единственный активный кандидат в ревьюверы
+	if _, err := prs.MergePR(asUser("u2"), "pr-001", 0); err != nil {
+		t.Errorf("Expected reviewer to merge, got %v", err)
+	}
+
+	if _, err := prs.MergePR(asUser("bot"), "pr-002", 0); err != nil {
+		t.Errorf("Expected bot to merge, got %v", err)
+	}
+}
+
+func TestRoles_ReviewSubmittedOnlyByReviewerOrBot(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	roles := NewRoleService(repos, logger)
+	prs := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "bot", Username: "CI", IsActive: false},
+	})
+
+	if _, err := roles.GrantRole(context.Background(), &model.RoleRequest{UserID: "bot", Role: model.RoleBot}); err != nil {
+		t.Fatalf("Failed to grant role: %v", err)
+	}
+
+	if _, err := prs.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID: "pr-001", PullRequestName: "Test PR", AuthorID: "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	// u2 — единственный активный кандидат в ревьюверы, автор голосует за него
+	review := &model.SubmitReviewRequest{PullRequestID: "pr-001", ReviewerID: "u2", Decision: model.ReviewDecisionApproved}
+	_, err := prs.SubmitReview(asUser("u1"), review)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeForbidden {
+		t.Errorf("Expected FORBIDDEN for another user, got %v", err)
+	}
+
+	if _, err := prs.SubmitReview(asUser("u2"), review); err != nil {
+		t.Errorf("Expected reviewer to submit review, got %v", err)
+	}
+
+	if _, err := prs.SubmitReview(asUser("bot"), review); err != nil {
+		t.Errorf("Expected bot to submit review, got %v", err)
+	}
+}
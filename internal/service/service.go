@@ -1,8 +1,11 @@
 package service
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
 )
 
 type Services struct {
@@ -10,13 +13,29 @@ type Services struct {
 	User        UserService
 	PullRequest PullRequestService
 	Stats       StatsService
+	Audit       AuditService
+	Auth        AuthService
+	Role        RoleService
+	Forge       ForgeService
+	Webhook     WebhookService
 }
 
-func NewServices(repos *repository.Repositories, logger *zap.Logger) *Services {
+func NewServices(repos *repository.Repositories, logger *zap.Logger, auth AuthOptions, forge ForgeOptions, webhooks WebhookOptions) *Services {
 	return &Services{
 		Team:        NewTeamService(repos, logger),
 		User:        NewUserService(repos, logger),
 		PullRequest: NewPullRequestService(repos, logger),
 		Stats:       NewStatsService(repos, logger),
+		Audit:       NewAuditService(repos, logger),
+		Auth:        NewAuthService(repos, logger, auth),
+		Role:        NewRoleService(repos, logger),
+		Forge:       NewForgeService(repos, logger, forge),
+		Webhook:     NewWebhookService(repos, logger, webhooks),
 	}
+}
+
+// logFor возвращает логгер текущего запроса (с его request_id), а вне
+// HTTP-запроса — логгер сервиса.
+func logFor(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	return requestctx.Logger(ctx, logger)
 }
\ No newline at end of file
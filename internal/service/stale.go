@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/notify"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// staleReviewLockKey — ключ advisory-блокировки, под которой выполняется обход.
+const staleReviewLockKey int64 = 0x5354414c45
+
+type StaleReviewService interface {
+	SweepStaleReviews(ctx context.Context) (*model.StaleSweepResult, error)
+}
+
+type staleReviewService struct {
+	repos           *repository.Repositories
+	logger          *zap.Logger
+	notifier        notify.Notifier
+	pullRequests    *pullRequestService
+	defaultSLAHours int
+	batchSize       int
+}
+
+func NewStaleReviewService(
+	repos *repository.Repositories,
+	logger *zap.Logger,
+	notifier notify.Notifier,
+	defaultSLAHours int,
+	batchSize int,
+) StaleReviewService {
+	return &staleReviewService{
+		repos:           repos,
+		logger:          logger,
+		notifier:        notifier,
+		pullRequests:    newPullRequestService(repos, logger),
+		defaultSLAHours: defaultSLAHours,
+		batchSize:       batchSize,
+	}
+}
+
+func (s *staleReviewService) SweepStaleReviews(ctx context.Context) (*model.StaleSweepResult, error) {
+	ctx, span := tracing.Start(ctx, "StaleReviewService.SweepStaleReviews")
+	defer span.End()
+
+	result := &model.StaleSweepResult{}
+
+	acquired, err := s.repos.Lock.TryWithLock(ctx, staleReviewLockKey, func() error {
+		reviews, err := s.repos.PullRequest.GetStaleReviews(ctx, s.defaultSLAHours, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, review := range reviews {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Ошибка по одному назначению не прерывает обход остальных.
+			if err := s.handleStaleReview(ctx, review, result); err != nil {
+				logFor(ctx, s.logger).Error("Failed to handle stale review",
+					zap.String("pr_id", review.PullRequestID),
+					zap.String("reviewer", review.ReviewerUserID),
+					zap.Error(err),
+				)
+				result.Failed++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to sweep stale reviews", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	result.Acquired = acquired
+
+	return result, nil
+}
+
+// handleStaleReview пропускает назначение, если PR изменили или смёржили
+// во время обхода: оно будет проверено заново при следующем обходе.
+func (s *staleReviewService) handleStaleReview(ctx context.Context, review model.StaleReview, result *model.StaleSweepResult) error {
+	err := s.escalate(ctx, review, result)
+	if err == sql.ErrNoRows {
+		logFor(ctx, s.logger).Info("Stale review skipped: PR changed during sweep",
+			zap.String("pr_id", review.PullRequestID),
+			zap.String("reviewer", review.ReviewerUserID),
+		)
+		return nil
+	}
+	return err
+}
+
+func (s *staleReviewService) escalate(ctx context.Context, review model.StaleReview, result *model.StaleSweepResult) error {
+	switch review.Policy {
+	case model.StalePolicyAddReviewer:
+		added, err := s.addReviewer(ctx, review)
+		if err != nil {
+			return err
+		}
+		if added {
+			result.Added++
+			return nil
+		}
+	case model.StalePolicyReassign:
+		reassigned, err := s.reassign(ctx, review)
+		if err != nil {
+			return err
+		}
+		if reassigned {
+			result.Reassigned++
+			return nil
+		}
+	}
+
+	// NUDGE, а также запасной вариант, когда подходящего кандидата нет.
+	if err := s.notifier.NotifyStaleReview(ctx, review); err != nil {
+		logFor(ctx, s.logger).Warn("Failed to notify stale reviewer",
+			zap.String("pr_id", review.PullRequestID),
+			zap.String("reviewer", review.ReviewerUserID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	if err := s.repos.PullRequest.MarkReviewEscalated(ctx, review.PRInternalID, review.ReviewerInternalID); err != nil {
+		return err
+	}
+	result.Nudged++
+
+	return nil
+}
+
+func (s *staleReviewService) addReviewer(ctx context.Context, review model.StaleReview) (bool, error) {
+	pr, candidate, err := s.findCandidate(ctx, review)
+	if err != nil || candidate == nil {
+		return false, err
+	}
+
+	if _, err := s.repos.PullRequest.AssignEscalationReviewer(ctx, pr.ID, review.ReviewerInternalID, candidate.ID, model.ReviewerActionStale, pr.Version); err != nil {
+		return false, err
+	}
+	_ = s.repos.Stats.RecordAssignment(ctx, candidate.ID, pr.ID)
+
+	before := *pr
+	before.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	pr.AssignedReviewers = append(pr.AssignedReviewers, candidate.UserID)
+	s.pullRequests.audit.record(ctx, AuditActionPRReassign, auditEntityPullRequest, pr.PullRequestID, before, pr)
+	s.pullRequests.events.reviewerAssigned(ctx, pr, candidate.UserID, model.ReviewerActionStale)
+
+	logFor(ctx, s.logger).Info("Extra reviewer added to stale PR",
+		zap.String("pr_id", review.PullRequestID),
+		zap.String("stale_reviewer", review.ReviewerUserID),
+		zap.String("new_reviewer", candidate.UserID),
+	)
+
+	return true, nil
+}
+
+func (s *staleReviewService) reassign(ctx context.Context, review model.StaleReview) (bool, error) {
+	pr, candidate, err := s.findCandidate(ctx, review)
+	if err != nil || candidate == nil {
+		return false, err
+	}
+
+	if _, err := s.repos.PullRequest.ReplaceReviewer(ctx, pr.ID, review.ReviewerInternalID, candidate.ID, model.ReviewerActionStale, pr.Version); err != nil {
+		return false, err
+	}
+	_ = s.repos.Stats.RecordAssignment(ctx, candidate.ID, pr.ID)
+
+	before := *pr
+	before.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	pr.AssignedReviewers = append(withoutReviewer(pr.AssignedReviewers, review.ReviewerUserID), candidate.UserID)
+	s.pullRequests.audit.record(ctx, AuditActionPRReassign, auditEntityPullRequest, pr.PullRequestID, before, pr)
+	reviewerReassignments.Inc(model.ReviewerActionStale)
+	s.pullRequests.events.reviewerReassigned(ctx, pr, review.ReviewerUserID, candidate.UserID, model.ReviewerActionStale)
+
+	logFor(ctx, s.logger).Info("Stale reviewer reassigned",
+		zap.String("pr_id", review.PullRequestID),
+		zap.String("old_reviewer", review.ReviewerUserID),
+		zap.String("new_reviewer", candidate.UserID),
+	)
+
+	return true, nil
+}
+
+// findCandidate подбирает ревьювера, не совпадающего с автором и текущими ревьюверами PR.
+func (s *staleReviewService) findCandidate(ctx context.Context, review model.StaleReview) (*model.PullRequest, *model.User, error) {
+	pr, err := s.repos.PullRequest.GetByPRID(ctx, review.PullRequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exclude := []string{review.AuthorInternalID, review.ReviewerInternalID}
+	for _, rUserID := range pr.AssignedReviewers {
+		u, err := s.repos.User.GetByUserID(ctx, rUserID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, err
+		}
+		if u != nil {
+			exclude = append(exclude, u.ID)
+		}
+	}
+
+	candidates, err := s.pullRequests.selectReviewersWithBalancing(ctx, review.TeamID, exclude, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(candidates) == 0 {
+		return pr, nil, nil
+	}
+
+	return pr, &candidates[0], nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type recordingNotifier struct {
+	reviews []model.StaleReview
+}
+
+func (n *recordingNotifier) NotifyStaleReview(ctx context.Context, review model.StaleReview) error {
+	n.reviews = append(n.reviews, review)
+	return nil
+}
+
+func TestSweepStaleReviews_Nudge(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	prService := NewPullRequestService(repos, logger)
+	notifier := &recordingNotifier{}
+	stale := NewStaleReviewService(repos, logger, notifier, 24, 100)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	if _, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE pr_reviewers SET assigned_at = NOW() - INTERVAL '2 days'`); err != nil {
+		t.Fatalf("Failed to backdate assignment: %v", err)
+	}
+
+	result, err := stale.SweepStaleReviews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to sweep stale reviews: %v", err)
+	}
+
+	if !result.Acquired || result.Nudged != 1 {
+		t.Errorf("Expected 1 nudge, got %+v", result)
+	}
+
+	if len(notifier.reviews) != 1 || notifier.reviews[0].ReviewerUserID != "u2" {
+		t.Errorf("Expected reminder for u2, got %v", notifier.reviews)
+	}
+
+	result, err = stale.SweepStaleReviews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to sweep stale reviews: %v", err)
+	}
+
+	if result.Nudged != 0 {
+		t.Errorf("Expected no repeated nudge within SLA, got %d", result.Nudged)
+	}
+}
+
+func TestSweepStaleReviews_SkipsReviewedPR(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	prService := NewPullRequestService(repos, logger)
+	notifier := &recordingNotifier{}
+	stale := NewStaleReviewService(repos, logger, notifier, 24, 100)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	if _, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if _, err := db.Exec(`UPDATE pr_reviewers SET assigned_at = NOW() - INTERVAL '2 days'`); err != nil {
+		t.Fatalf("Failed to backdate assignment: %v", err)
+	}
+
+	if _, err := prService.SubmitReview(context.Background(), &model.SubmitReviewRequest{
+		PullRequestID: "pr-001",
+		ReviewerID:    "u2",
+		Decision:      model.ReviewDecisionChangesRequested,
+	}); err != nil {
+		t.Fatalf("Failed to submit review: %v", err)
+	}
+
+	result, err := stale.SweepStaleReviews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to sweep stale reviews: %v", err)
+	}
+
+	if result.Nudged != 0 || len(notifier.reviews) != 0 {
+		t.Errorf("Expected no reminder after review decision, got %+v", result)
+	}
+}
+
+func TestSweepStaleReviews_Reassign(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	teamService := NewTeamService(repos, logger)
+	prService := NewPullRequestService(repos, logger)
+	stale := NewStaleReviewService(repos, logger, &recordingNotifier{}, 0, 100)
+
+	sla := 4
+	if _, err := teamService.CreateTeam(context.Background(), &model.Team{
+		TeamName:       "backend",
+		ReviewSLAHours: &sla,
+		StalePolicy:    model.StalePolicyReassign,
+		Members: []model.TeamMember{
+			{UserID: "u1", Username: "Alice", IsActive: true},
+			{UserID: "u2", Username: "Bob", IsActive: true},
+			{UserID: "u3", Username: "Charlie", IsActive: true},
+			{UserID: "u4", Username: "David", IsActive: true},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to create team: %v", err)
+	}
+
+	pr, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	staleReviewer := pr.AssignedReviewers[0]
+	if _, err := db.Exec(`
+		UPDATE pr_reviewers SET assigned_at = NOW() - INTERVAL '5 hours'
+		WHERE user_id = (SELECT id FROM users WHERE user_id = $1)
+	`, staleReviewer); err != nil {
+		t.Fatalf("Failed to backdate assignment: %v", err)
+	}
+
+	result, err := stale.SweepStaleReviews(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to sweep stale reviews: %v", err)
+	}
+
+	if result.Reassigned != 1 {
+		t.Errorf("Expected 1 reassignment, got %+v", result)
+	}
+
+	updated, err := repos.PullRequest.GetByPRID(context.Background(), "pr-001")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+
+	for _, r := range updated.AssignedReviewers {
+		if r == staleReviewer {
+			t.Error("Stale reviewer should be reassigned")
+		}
+	}
+}
@@ -1,19 +1,27 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
 	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
 )
 
 type StatsService interface {
-	GetUserStats() ([]struct {
-		UserID          string `json:"user_id"`
-		AssignmentCount int    `json:"assignment_count"`
-	}, error)
-	GetPRStats() ([]struct {
-		PRID          string `json:"pr_id"`
-		ReviewerCount int    `json:"reviewer_count"`
-	}, error)
+	GetUserStats(ctx context.Context, teamName string, filter model.StatsFilter) (*model.UserAssignmentStatsList, error)
+	GetPRStats(ctx context.Context, teamName string, filter model.StatsFilter) (*model.PRReviewerStatsList, error)
+	ExportUserStats(ctx context.Context, teamName string, filter model.StatsFilter, fn func(model.UserAssignmentStat) error) error
+	ExportPRStats(ctx context.Context, teamName string, filter model.StatsFilter, fn func(model.PRReviewerStat) error) error
+	GetTurnaroundStats(ctx context.Context, from, to time.Time) (*model.TurnaroundStats, error)
+	GetFairnessReport(ctx context.Context, teamName string, from, to time.Time, outlierStddev float64) (*model.FairnessReport, error)
+	GetOpenReviewLoad(ctx context.Context) ([]model.OpenReviewLoad, error)
 }
 
 type statsService struct {
@@ -28,48 +36,262 @@ func NewStatsService(repos *repository.Repositories, logger *zap.Logger) StatsSe
 	}
 }
 
-func (s *statsService) GetUserStats() ([]struct {
-	UserID          string `json:"user_id"`
-	AssignmentCount int    `json:"assignment_count"`
-}, error) {
-	stats, err := s.repos.Stats.GetUserStats()
+func (s *statsService) GetUserStats(ctx context.Context, teamName string, filter model.StatsFilter) (*model.UserAssignmentStatsList, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetUserStats")
+	defer span.End()
+
+	filter, err := s.resolveStatsFilter(ctx, teamName, filter)
 	if err != nil {
-		s.logger.Error("Failed to get user stats", zap.Error(err))
 		return nil, err
 	}
-	
-	result := make([]struct {
-		UserID          string `json:"user_id"`
-		AssignmentCount int    `json:"assignment_count"`
-	}, len(stats))
-	
-	for i, st := range stats {
-		result[i].UserID = st.UserID
-		result[i].AssignmentCount = st.AssignmentCount
-	}
-	
-	return result, nil
+
+	stats, total, err := s.repos.Stats.GetUserStats(ctx, filter)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user stats", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.UserAssignmentStatsList{
+		Stats:  stats,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
 }
 
-func (s *statsService) GetPRStats() ([]struct {
-	PRID          string `json:"pr_id"`
-	ReviewerCount int    `json:"reviewer_count"`
-}, error) {
-	stats, err := s.repos.Stats.GetPRStats()
+func (s *statsService) GetPRStats(ctx context.Context, teamName string, filter model.StatsFilter) (*model.PRReviewerStatsList, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetPRStats")
+	defer span.End()
+
+	filter, err := s.resolveStatsFilter(ctx, teamName, filter)
 	if err != nil {
-		s.logger.Error("Failed to get PR stats", zap.Error(err))
 		return nil, err
 	}
-	
-	result := make([]struct {
-		PRID          string `json:"pr_id"`
-		ReviewerCount int    `json:"reviewer_count"`
-	}, len(stats))
-	
-	for i, st := range stats {
-		result[i].PRID = st.PRID
-		result[i].ReviewerCount = st.ReviewerCount
-	}
-	
-	return result, nil
-}
\ No newline at end of file
+
+	stats, total, err := s.repos.Stats.GetPRStats(ctx, filter)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get PR stats", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.PRReviewerStatsList{
+		Stats:  stats,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+func (s *statsService) ExportUserStats(ctx context.Context, teamName string, filter model.StatsFilter, fn func(model.UserAssignmentStat) error) error {
+	ctx, span := tracing.Start(ctx, "StatsService.ExportUserStats")
+	defer span.End()
+
+	filter, err := s.resolveStatsFilter(ctx, teamName, filter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repos.Stats.StreamUserStats(ctx, filter, fn); err != nil {
+		logFor(ctx, s.logger).Error("Failed to export user stats", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	return nil
+}
+
+func (s *statsService) ExportPRStats(ctx context.Context, teamName string, filter model.StatsFilter, fn func(model.PRReviewerStat) error) error {
+	ctx, span := tracing.Start(ctx, "StatsService.ExportPRStats")
+	defer span.End()
+
+	filter, err := s.resolveStatsFilter(ctx, teamName, filter)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repos.Stats.StreamPRStats(ctx, filter, fn); err != nil {
+		logFor(ctx, s.logger).Error("Failed to export PR stats", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	return nil
+}
+
+// resolveStatsFilter проверяет период и подставляет ID команды по имени.
+func (s *statsService) resolveStatsFilter(ctx context.Context, teamName string, filter model.StatsFilter) (model.StatsFilter, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, errors.ErrBadRequest("from must be before to")
+	}
+
+	if teamName != "" {
+		teamID, err := s.repos.Team.GetIDByName(ctx, teamName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return filter, errors.ErrNotFound("team")
+			}
+			logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+			return filter, errors.ErrInternal(err)
+		}
+		filter.TeamID = teamID
+	}
+
+	return filter, nil
+}
+
+func (s *statsService) GetTurnaroundStats(ctx context.Context, from, to time.Time) (*model.TurnaroundStats, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetTurnaroundStats")
+	defer span.End()
+
+	if !from.Before(to) {
+		return nil, errors.ErrBadRequest("from must be before to")
+	}
+
+	userRows, err := s.repos.Stats.GetTurnaround(ctx, "user", from, to)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user turnaround stats", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	teamRows, err := s.repos.Stats.GetTurnaround(ctx, "team", from, to)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get team turnaround stats", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	stats := &model.TurnaroundStats{
+		From:  from,
+		To:    to,
+		Users: make([]model.TurnaroundEntry, len(userRows)),
+		Teams: make([]model.TurnaroundEntry, len(teamRows)),
+	}
+
+	for i, row := range userRows {
+		stats.Users[i] = turnaroundEntry(row)
+		stats.Users[i].UserID = row.Key
+	}
+
+	for i, row := range teamRows {
+		stats.Teams[i] = turnaroundEntry(row)
+		stats.Teams[i].TeamName = row.Key
+	}
+
+	return stats, nil
+}
+
+func turnaroundEntry(row model.TurnaroundRow) model.TurnaroundEntry {
+	return model.TurnaroundEntry{
+		TimeToFirstDecision:  durationStats(row.DecisionCount, row.DecisionP50, row.DecisionP90),
+		AssignmentToApproval: durationStats(row.ApprovalCount, row.ApprovalP50, row.ApprovalP90),
+		OpenToMerge:          durationStats(row.MergeCount, row.MergeP50, row.MergeP90),
+	}
+}
+
+func durationStats(count int, p50, p90 sql.NullFloat64) model.DurationStats {
+	stats := model.DurationStats{Count: count}
+	if p50.Valid {
+		stats.P50Seconds = &p50.Float64
+	}
+	if p90.Valid {
+		stats.P90Seconds = &p90.Float64
+	}
+	return stats
+}
+
+func (s *statsService) GetOpenReviewLoad(ctx context.Context) ([]model.OpenReviewLoad, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetOpenReviewLoad")
+	defer span.End()
+
+	load, err := s.repos.Stats.GetOpenReviewLoad(ctx)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get open review load", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return load, nil
+}
+
+// GetFairnessReport оценивает равномерность нагрузки внутри команд.
+// Число назначений каждого участника приводится к полному окну с учётом
+// доли времени, когда он был активен: иначе новички и вернувшиеся из
+// отпуска выглядели бы недогруженными.
+func (s *statsService) GetFairnessReport(ctx context.Context, teamName string, from, to time.Time, outlierStddev float64) (*model.FairnessReport, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetFairnessReport")
+	defer span.End()
+
+	filter, err := s.resolveStatsFilter(ctx, teamName, model.StatsFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repos.Stats.GetFairness(ctx, filter.TeamID, from, to)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get fairness stats", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	window := to.Sub(from).Seconds()
+	report := &model.FairnessReport{
+		From:          from,
+		To:            to,
+		OutlierStddev: outlierStddev,
+		Teams:         []model.FairnessTeam{},
+	}
+
+	for _, row := range rows {
+		if len(report.Teams) == 0 || report.Teams[len(report.Teams)-1].TeamName != row.TeamName {
+			report.Teams = append(report.Teams, model.FairnessTeam{TeamName: row.TeamName})
+		}
+		team := &report.Teams[len(report.Teams)-1]
+
+		ratio := math.Min(row.ActiveSeconds/window, 1)
+		team.Members = append(team.Members, model.FairnessMember{
+			UserID:          row.UserID,
+			AssignmentCount: row.AssignmentCount,
+			ActiveRatio:     ratio,
+			NormalizedCount: float64(row.AssignmentCount) / ratio,
+		})
+	}
+
+	for i := range report.Teams {
+		fillFairness(&report.Teams[i], outlierStddev)
+	}
+
+	return report, nil
+}
+
+func fillFairness(team *model.FairnessTeam, outlierStddev float64) {
+	values := make([]float64, len(team.Members))
+	var sum float64
+	for i, member := range team.Members {
+		values[i] = member.NormalizedCount
+		sum += member.NormalizedCount
+	}
+	sort.Float64s(values)
+
+	n := float64(len(values))
+	team.Min = values[0]
+	team.Max = values[len(values)-1]
+	team.Mean = sum / n
+
+	var variance, weighted float64
+	for i, v := range values {
+		variance += (v - team.Mean) * (v - team.Mean)
+		weighted += float64(i+1) * v
+	}
+	team.Stddev = math.Sqrt(variance / n)
+
+	if sum > 0 {
+		team.Gini = 2*weighted/(n*sum) - (n+1)/n
+	}
+
+	if team.Min > 0 {
+		ratio := team.Max / team.Min
+		team.MaxMinRatio = &ratio
+	}
+
+	if team.Stddev > 0 {
+		threshold := team.Mean + outlierStddev*team.Stddev
+		for i := range team.Members {
+			team.Members[i].IsOutlier = team.Members[i].NormalizedCount > threshold
+		}
+	}
+}
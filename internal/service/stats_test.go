@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestGetTurnaroundStats(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	prService := NewPullRequestService(repos, logger)
+	statsService := NewStatsService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	ctx := context.Background()
+	if _, err := prService.CreatePR(ctx, &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if _, err := prService.SubmitReview(ctx, &model.SubmitReviewRequest{
+		PullRequestID: "pr-001",
+		ReviewerID:    "u1",
+		Decision:      model.ReviewDecisionApproved,
+	}); err == nil {
+		t.Error("Expected error for review by unassigned user")
+	}
+
+	if _, err := prService.SubmitReview(ctx, &model.SubmitReviewRequest{
+		PullRequestID: "pr-001",
+		ReviewerID:    "u2",
+		Decision:      model.ReviewDecisionChangesRequested,
+	}); err != nil {
+		t.Fatalf("Failed to submit review: %v", err)
+	}
+
+	if _, err := prService.SubmitReview(ctx, &model.SubmitReviewRequest{
+		PullRequestID: "pr-001",
+		ReviewerID:    "u2",
+		Decision:      model.ReviewDecisionApproved,
+	}); err != nil {
+		t.Fatalf("Failed to submit review: %v", err)
+	}
+
+	if _, err := prService.MergePR(ctx, "pr-001", 0); err != nil {
+		t.Fatalf("Failed to merge PR: %v", err)
+	}
+
+	stats, err := statsService.GetTurnaroundStats(context.Background(), time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get turnaround stats: %v", err)
+	}
+
+	if len(stats.Users) != 2 {
+		t.Fatalf("Expected entries for reviewer and author, got %d", len(stats.Users))
+	}
+
+	for _, entry := range stats.Users {
+		switch entry.UserID {
+		case "u1":
+			if entry.OpenToMerge.Count != 1 || entry.TimeToFirstDecision.Count != 0 {
+				t.Errorf("Unexpected author stats: %+v", entry)
+			}
+		case "u2":
+			if entry.TimeToFirstDecision.Count != 1 || entry.AssignmentToApproval.Count != 1 {
+				t.Errorf("Unexpected reviewer stats: %+v", entry)
+			}
+			if entry.TimeToFirstDecision.P50Seconds == nil {
+				t.Error("Expected p50 for time to first decision")
+			}
+		default:
+			t.Errorf("Unexpected user %s", entry.UserID)
+		}
+	}
+
+	if len(stats.Teams) != 1 || stats.Teams[0].TeamName != "backend" {
+		t.Errorf("Expected backend team entry, got %+v", stats.Teams)
+	}
+}
+
+func TestGetUserStats_Filters(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	prService := NewPullRequestService(repos, logger)
+	statsService := NewStatsService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "David", IsActive: true},
+	})
+
+	ctx := context.Background()
+	for _, req := range []model.CreatePRRequest{
+		{PullRequestID: "pr-001", PullRequestName: "Backend PR", AuthorID: "u1"},
+		{PullRequestID: "pr-002", PullRequestName: "Frontend PR", AuthorID: "u3"},
+		{PullRequestID: "pr-003", PullRequestName: "Frontend PR 2", AuthorID: "u3"},
+	} {
+		req := req
+		if _, err := prService.CreatePR(ctx, &req); err != nil {
+			t.Fatalf("Failed to create PR: %v", err)
+		}
+	}
+
+	if _, err := prService.MergePR(ctx, "pr-003", 0); err != nil {
+		t.Fatalf("Failed to merge PR: %v", err)
+	}
+
+	stats, err := statsService.GetUserStats(context.Background(), "frontend", model.StatsFilter{Limit: 50})
+	if err != nil {
+		t.Fatalf("Failed to get user stats: %v", err)
+	}
+
+	if stats.Total != 1 || stats.Stats[0].UserID != "u4" || stats.Stats[0].AssignmentCount != 2 {
+		t.Errorf("Expected u4 with 2 assignments, got %+v", stats)
+	}
+
+	stats, err = statsService.GetUserStats(context.Background(), "frontend", model.StatsFilter{Status: "MERGED", GroupBy: "day", Limit: 50})
+	if err != nil {
+		t.Fatalf("Failed to get user stats: %v", err)
+	}
+
+	if stats.Total != 1 || stats.Stats[0].AssignmentCount != 1 || stats.Stats[0].Bucket == nil {
+		t.Errorf("Expected one daily bucket for merged PR, got %+v", stats)
+	}
+
+	prStats, err := statsService.GetPRStats(context.Background(), "", model.StatsFilter{
+		From:  time.Now().Add(time.Hour),
+		Limit: 50,
+	})
+	if err != nil {
+		t.Fatalf("Failed to get PR stats: %v", err)
+	}
+
+	if prStats.Total != 0 {
+		t.Errorf("Expected no PR stats in future window, got %d", prStats.Total)
+	}
+
+	prStats, err = statsService.GetPRStats(context.Background(), "", model.StatsFilter{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("Failed to get PR stats: %v", err)
+	}
+
+	if prStats.Total != 3 || len(prStats.Stats) != 1 {
+		t.Errorf("Expected page of 1 out of 3 PRs, got %+v", prStats)
+	}
+
+	if _, err := statsService.GetUserStats(context.Background(), "unknown", model.StatsFilter{Limit: 50}); err == nil {
+		t.Error("Expected error for unknown team")
+	}
+}
+
+func TestFillFairness(t *testing.T) {
+	team := &model.FairnessTeam{
+		TeamName: "backend",
+		Members: []model.FairnessMember{
+			{UserID: "u1", NormalizedCount: 2},
+			{UserID: "u2", NormalizedCount: 2},
+			{UserID: "u3", NormalizedCount: 2},
+			{UserID: "u4", NormalizedCount: 10},
+		},
+	}
+
+	fillFairness(team, 1)
+
+	if team.Min != 2 || team.Max != 10 || team.Mean != 4 {
+		t.Errorf("Unexpected min/max/mean: %v/%v/%v", team.Min, team.Max, team.Mean)
+	}
+
+	if math.Abs(team.Stddev-math.Sqrt(12)) > 1e-9 {
+		t.Errorf("Expected stddev sqrt(12), got %v", team.Stddev)
+	}
+
+	if math.Abs(team.Gini-0.375) > 1e-9 {
+		t.Errorf("Expected gini 0.375, got %v", team.Gini)
+	}
+
+	if team.MaxMinRatio == nil || *team.MaxMinRatio != 5 {
+		t.Errorf("Expected max/min ratio 5, got %v", team.MaxMinRatio)
+	}
+
+	for _, member := range team.Members {
+		if member.IsOutlier != (member.UserID == "u4") {
+			t.Errorf("Unexpected outlier flag for %s: %v", member.UserID, member.IsOutlier)
+		}
+	}
+}
+
+func TestGetFairnessReport_AccountsForActiveTime(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	statsService := NewStatsService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	now := time.Now()
+	if _, err := db.Exec(`UPDATE user_activity_events SET created_at = $1`, now.Add(-10*time.Hour)); err != nil {
+		t.Fatalf("Failed to backdate activity: %v", err)
+	}
+	if _, err := db.Exec(`
+		UPDATE user_activity_events SET created_at = $1
+		WHERE user_id = (SELECT id FROM users WHERE user_id = 'u2')
+	`, now.Add(-5*time.Hour)); err != nil {
+		t.Fatalf("Failed to backdate activity: %v", err)
+	}
+
+	report, err := statsService.GetFairnessReport(context.Background(), "backend", now.Add(-10*time.Hour), now, 2)
+	if err != nil {
+		t.Fatalf("Failed to get fairness report: %v", err)
+	}
+
+	if len(report.Teams) != 1 || len(report.Teams[0].Members) != 2 {
+		t.Fatalf("Expected one team with two members, got %+v", report.Teams)
+	}
+
+	for _, member := range report.Teams[0].Members {
+		expected := 1.0
+		if member.UserID == "u2" {
+			expected = 0.5
+		}
+		if math.Abs(member.ActiveRatio-expected) > 0.01 {
+			t.Errorf("Expected active ratio %v for %s, got %v", expected, member.UserID, member.ActiveRatio)
+		}
+	}
+}
+
+func TestExportPRStats_StreamsAllRows(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	prService := NewPullRequestService(repos, logger)
+	statsService := NewStatsService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+
+	ctx := context.Background()
+	for _, prID := range []string{"pr-001", "pr-002", "pr-003"} {
+		if _, err := prService.CreatePR(ctx, &model.CreatePRRequest{
+			PullRequestID:   prID,
+			PullRequestName: "Test PR",
+			AuthorID:        "u1",
+		}); err != nil {
+			t.Fatalf("Failed to create PR: %v", err)
+		}
+	}
+
+	var exported []model.PRReviewerStat
+	err := statsService.ExportPRStats(context.Background(), "", model.StatsFilter{}, func(stat model.PRReviewerStat) error {
+		exported = append(exported, stat)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to export PR stats: %v", err)
+	}
+
+	if len(exported) != 3 {
+		t.Fatalf("Expected 3 exported rows, got %d", len(exported))
+	}
+
+	for _, stat := range exported {
+		if stat.ReviewerCount != 2 {
+			t.Errorf("Expected 2 reviewers for %s, got %d", stat.PRID, stat.ReviewerCount)
+		}
+	}
+}
@@ -1,35 +1,54 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 
 	"go.uber.org/zap"
 	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
 )
 
 type TeamService interface {
-	CreateTeam(team *model.Team) (*model.Team, error)
-	GetTeam(teamName string) (*model.Team, error)
+	CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error)
+	GetTeam(ctx context.Context, teamName string) (*model.Team, error)
+	AddMember(ctx context.Context, req *model.AddTeamMemberRequest) (*model.Team, error)
+	RemoveMember(ctx context.Context, teamName, userID string) (*model.Team, error)
+	MoveMember(ctx context.Context, userID, fromTeamName, toTeamName string) (*model.User, error)
+	SetMemberIsActive(ctx context.Context, teamName, userID string, isActive bool) (*model.Team, error)
+	GetTeamTree(ctx context.Context, teamName string) (*model.Team, error)
+	UpdateTeam(ctx context.Context, req *model.UpdateTeamRequest) (*model.Team, error)
+	SetIsArchived(ctx context.Context, teamName string, isArchived bool) (*model.Team, error)
+	DeleteTeam(ctx context.Context, teamName, targetTeamName string) error
 }
 
 type teamService struct {
-	repos  *repository.Repositories
-	logger *zap.Logger
+	repos        *repository.Repositories
+	logger       *zap.Logger
+	audit        *auditRecorder
+	authz        *authorizer
+	pullRequests *pullRequestService
 }
 
 func NewTeamService(repos *repository.Repositories, logger *zap.Logger) TeamService {
 	return &teamService{
-		repos:  repos,
-		logger: logger,
+		repos:        repos,
+		logger:       logger,
+		audit:        newAuditRecorder(repos, logger),
+		authz:        newAuthorizer(repos, logger),
+		pullRequests: newPullRequestService(repos, logger),
 	}
 }
 
-func (s *teamService) CreateTeam(team *model.Team) (*model.Team, error) {
-	exists, err := s.repos.Team.Exists(team.TeamName)
+func (s *teamService) CreateTeam(ctx context.Context, team *model.Team) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.CreateTeam")
+	defer span.End()
+
+	exists, err := s.repos.Team.Exists(ctx, team.TeamName)
 	if err != nil {
-		s.logger.Error("Failed to check team existence", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to check team existence", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
@@ -37,36 +56,643 @@ func (s *teamService) CreateTeam(team *model.Team) (*model.Team, error) {
 		return nil, errors.ErrTeamExists(team.TeamName)
 	}
 
-	teamID, err := s.repos.Team.Create(team.TeamName)
+	var parentTeamID *string
+	if team.ParentTeamName != "" {
+		id, err := s.getTeamID(ctx, team.ParentTeamName)
+		if err != nil {
+			return nil, err
+		}
+		parentTeamID = &id
+	}
+
+	// Корневую команду создаёт администратор, вложенную — ещё и лид родительской.
+	parentID := ""
+	if parentTeamID != nil {
+		parentID = *parentTeamID
+	}
+	if err := s.authz.requireTeamLead(ctx, parentID, team.ParentTeamName); err != nil {
+		return nil, err
+	}
+
+	if team.MaxOpenReviews != nil && *team.MaxOpenReviews < 0 {
+		return nil, errors.ErrBadRequest("max_open_reviews must not be negative")
+	}
+
+	if team.ReviewSLAHours != nil && *team.ReviewSLAHours < 0 {
+		return nil, errors.ErrBadRequest("review_sla_hours must not be negative")
+	}
+
+	// Нулевые лимит и SLA означают «не задано», как и в UpdateTeam.
+	settings := *team
+	if settings.MaxOpenReviews != nil && *settings.MaxOpenReviews == 0 {
+		settings.MaxOpenReviews = nil
+	}
+	if settings.ReviewSLAHours != nil && *settings.ReviewSLAHours == 0 {
+		settings.ReviewSLAHours = nil
+	}
+
+	if _, err := s.repos.Team.CreateWithMembers(ctx, &settings, parentTeamID); err != nil {
+		if deleted, ok := err.(*repository.UserDeletedError); ok {
+			return nil, errors.ErrUserDeleted(deleted.UserID)
+		}
+		logFor(ctx, s.logger).Error("Failed to create team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team created successfully", zap.String("team_name", team.TeamName))
+
+	created, err := s.GetTeam(ctx, team.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, AuditActionTeamCreate, auditEntityTeam, created.TeamName, nil, created)
+
+	return created, nil
+}
+
+func (s *teamService) GetTeam(ctx context.Context, teamName string) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeam")
+	defer span.End()
+
+	team, err := s.repos.Team.Get(ctx, teamName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return team, nil
+}
+
+func (s *teamService) AddMember(ctx context.Context, req *model.AddTeamMemberRequest) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.AddMember")
+	defer span.End()
+
+	teamID, err := s.getActiveTeamID(ctx, req.TeamName)
 	if err != nil {
-		s.logger.Error("Failed to create team", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, req.TeamName); err != nil {
+		return nil, err
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	user, err := s.repos.User.GetByUserID(ctx, req.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
-	for _, member := range team.Members {
-		if err := s.repos.User.Upsert(member.UserID, member.Username, teamID, member.IsActive); err != nil {
-			s.logger.Error("Failed to upsert user",
-				zap.String("user_id", member.UserID),
+	if user == nil {
+		if err := s.repos.User.Ensure(ctx, req.UserID, req.Username, "", req.IsActive); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.ErrUserDeleted(req.UserID)
+			}
+			logFor(ctx, s.logger).Error("Failed to add user",
+				zap.String("user_id", req.UserID),
 				zap.Error(err),
 			)
 			return nil, errors.ErrInternal(err)
 		}
+
+		user, err = s.repos.User.GetByUserID(ctx, req.UserID)
+		if err != nil {
+			logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+	} else {
+		_, err := s.repos.Membership.Get(ctx, user.ID, teamID)
+		if err == nil {
+			return nil, errors.ErrMemberExists(req.UserID, req.TeamName)
+		}
+		if err != sql.ErrNoRows {
+			logFor(ctx, s.logger).Error("Failed to get team membership", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+	}
+
+	if err := s.repos.Membership.Add(ctx, user.ID, teamID, req.IsPrimary, req.IsActive); err != nil {
+		logFor(ctx, s.logger).Error("Failed to add team membership", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.repos.Team.LogMembershipChange(ctx, user.ID, "ADD", nil, &teamID); err != nil {
+		logFor(ctx, s.logger).Error("Failed to log membership change", zap.Error(err))
+		return nil, errors.ErrInternal(err)
 	}
 
-	s.logger.Info("Team created successfully", zap.String("team_name", team.TeamName))
+	logFor(ctx, s.logger).Info("Team member added",
+		zap.String("team_name", req.TeamName),
+		zap.String("user_id", req.UserID),
+	)
 
-	return s.GetTeam(team.TeamName)
+	return s.recordTeamChange(ctx, AuditActionTeamAddMember, req.TeamName, before)
 }
 
-func (s *teamService) GetTeam(teamName string) (*model.Team, error) {
-	team, err := s.repos.Team.Get(teamName)
+func (s *teamService) RemoveMember(ctx context.Context, teamName, userID string) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.RemoveMember")
+	defer span.End()
+
+	teamID, err := s.getTeamID(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, teamName); err != nil {
+		return nil, err
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	user, err := s.getMember(ctx, userID, teamID, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Membership.Remove(ctx, user.ID, teamID); err != nil {
+		logFor(ctx, s.logger).Error("Failed to remove team membership", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.repos.Team.LogMembershipChange(ctx, user.ID, "REMOVE", &teamID, nil); err != nil {
+		logFor(ctx, s.logger).Error("Failed to log membership change", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	reassigned, err := s.pullRequests.reassignOpenReviews(ctx, user, teamID, model.ReviewerActionTeamChange)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to reassign open reviews", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team member removed",
+		zap.String("team_name", teamName),
+		zap.String("user_id", userID),
+		zap.Int("reassigned_reviews", reassigned),
+	)
+
+	return s.recordTeamChange(ctx, AuditActionTeamRemoveMember, teamName, before)
+}
+
+func (s *teamService) MoveMember(ctx context.Context, userID, fromTeamName, toTeamName string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.MoveMember")
+	defer span.End()
+
+	toTeamID, err := s.getActiveTeamID(ctx, toTeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repos.User.GetByUserID(ctx, userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if fromTeamName == "" {
+		if user.TeamID == "" {
+			return nil, errors.ErrNotFound("user team")
+		}
+		fromTeamName = user.TeamName
+	}
+
+	fromTeamID, err := s.getTeamID(ctx, fromTeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, fromTeamID, fromTeamName); err != nil {
+		return nil, err
+	}
+	if err := s.authz.requireTeamLead(ctx, toTeamID, toTeamName); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getMember(ctx, userID, fromTeamID, fromTeamName); err != nil {
+		return nil, err
+	}
+
+	before, err := s.getUserWithTeams(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repos.Membership.Get(ctx, user.ID, toTeamID)
+	if err == nil {
+		return nil, errors.ErrMemberExists(userID, toTeamName)
+	}
+	if err != sql.ErrNoRows {
+		logFor(ctx, s.logger).Error("Failed to get team membership", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.repos.Membership.Move(ctx, user.ID, fromTeamID, toTeamID); err != nil {
+		logFor(ctx, s.logger).Error("Failed to move user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if err := s.repos.Team.LogMembershipChange(ctx, user.ID, "MOVE", &fromTeamID, &toTeamID); err != nil {
+		logFor(ctx, s.logger).Error("Failed to log membership change", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	reassigned, err := s.pullRequests.reassignOpenReviews(ctx, user, fromTeamID, model.ReviewerActionTeamChange)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to reassign open reviews", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team member moved",
+		zap.String("user_id", userID),
+		zap.String("from_team", fromTeamName),
+		zap.String("to_team", toTeamName),
+		zap.Int("reassigned_reviews", reassigned),
+	)
+
+	after, err := s.getUserWithTeams(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, AuditActionTeamMoveMember, auditEntityUser, userID, before, after)
+
+	return after, nil
+}
+
+func (s *teamService) SetMemberIsActive(ctx context.Context, teamName, userID string, isActive bool) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.SetMemberIsActive")
+	defer span.End()
+
+	teamID, err := s.getTeamID(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, teamName); err != nil {
+		return nil, err
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	user, err := s.getMember(ctx, userID, teamID, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.Membership.SetIsActive(ctx, user.ID, teamID, isActive); err != nil {
+		logFor(ctx, s.logger).Error("Failed to set membership active status", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	reassigned := 0
+	if !isActive {
+		reassigned, err = s.pullRequests.reassignOpenReviews(ctx, user, teamID, model.ReviewerActionDeactivation)
+		if err != nil {
+			logFor(ctx, s.logger).Error("Failed to reassign open reviews", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+	}
+
+	logFor(ctx, s.logger).Info("Team membership active status updated",
+		zap.String("team_name", teamName),
+		zap.String("user_id", userID),
+		zap.Bool("is_active", isActive),
+		zap.Int("reassigned_reviews", reassigned),
+	)
+
+	return s.recordTeamChange(ctx, AuditActionTeamSetMemberIsActive, teamName, before)
+}
+
+func (s *teamService) UpdateTeam(ctx context.Context, req *model.UpdateTeamRequest) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.UpdateTeam")
+	defer span.End()
+
+	teamID, err := s.getTeamID(ctx, req.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, req.TeamName); err != nil {
+		return nil, err
+	}
+
+	// Сначала проверяются все поля, чтобы не применить запрос частично.
+	update := model.TeamUpdate{}
+	teamName := req.TeamName
+
+	if req.ParentTeamName != nil {
+		update.SetParent = true
+		if *req.ParentTeamName != "" {
+			id, err := s.getTeamID(ctx, *req.ParentTeamName)
+			if err != nil {
+				return nil, err
+			}
+			if err := s.authz.requireTeamLead(ctx, id, *req.ParentTeamName); err != nil {
+				return nil, err
+			}
+			if err := s.checkNoCycle(ctx, teamID, id); err != nil {
+				return nil, err
+			}
+			update.ParentTeamID = &id
+		}
+	}
+
+	if req.MaxOpenReviews != nil {
+		if *req.MaxOpenReviews < 0 {
+			return nil, errors.ErrBadRequest("max_open_reviews must not be negative")
+		}
+		update.SetMaxOpenReviews = true
+		if *req.MaxOpenReviews > 0 {
+			update.MaxOpenReviews = req.MaxOpenReviews
+		}
+	}
+
+	if req.ReviewSLAHours != nil {
+		if *req.ReviewSLAHours < 0 {
+			return nil, errors.ErrBadRequest("review_sla_hours must not be negative")
+		}
+		update.SetReviewSLA = true
+		if *req.ReviewSLAHours > 0 {
+			update.ReviewSLAHours = req.ReviewSLAHours
+		}
+	}
+
+	if req.StalePolicy != nil && *req.StalePolicy != "" {
+		update.StalePolicy = req.StalePolicy
+	}
+
+	if req.NewTeamName != "" && req.NewTeamName != teamName {
+		exists, err := s.repos.Team.Exists(ctx, req.NewTeamName)
+		if err != nil {
+			logFor(ctx, s.logger).Error("Failed to check team existence", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+		if exists {
+			return nil, errors.ErrTeamExists(req.NewTeamName)
+		}
+		update.TeamName = &req.NewTeamName
+		teamName = req.NewTeamName
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	if err := s.repos.Team.Update(ctx, teamID, update); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.ErrNotFound("team")
 		}
-		s.logger.Error("Failed to get team", zap.Error(err))
+		logFor(ctx, s.logger).Error("Failed to update team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team updated",
+		zap.String("team_name", req.TeamName),
+		zap.String("new_team_name", teamName),
+	)
+
+	return s.recordTeamChange(ctx, AuditActionTeamUpdate, teamName, before)
+}
+
+func (s *teamService) GetTeamTree(ctx context.Context, teamName string) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeamTree")
+	defer span.End()
+
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadSubTeams(ctx, team, 0); err != nil {
+		logFor(ctx, s.logger).Error("Failed to load sub-teams", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	return team, nil
-}
\ No newline at end of file
+}
+
+func (s *teamService) loadSubTeams(ctx context.Context, team *model.Team, depth int) error {
+	if depth >= repository.MaxTeamDepth {
+		return nil
+	}
+
+	childIDs, err := s.repos.Team.GetChildIDs(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, childID := range childIDs {
+		child, err := s.repos.Team.GetByID(ctx, childID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.loadSubTeams(ctx, child, depth+1); err != nil {
+			return err
+		}
+
+		team.SubTeams = append(team.SubTeams, *child)
+	}
+
+	return nil
+}
+
+// checkNoCycle запрещает назначать родителем саму команду или её потомка.
+func (s *teamService) checkNoCycle(ctx context.Context, teamID, parentTeamID string) error {
+	ancestors, err := s.repos.Team.GetAncestors(ctx, parentTeamID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get team ancestors", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	for _, ancestor := range ancestors {
+		if ancestor.ID == teamID {
+			return errors.ErrBadRequest("parent_team_name would create a cycle in team hierarchy")
+		}
+	}
+
+	if len(ancestors) >= repository.MaxTeamDepth {
+		return errors.ErrBadRequest("team hierarchy is too deep")
+	}
+
+	return nil
+}
+
+func (s *teamService) SetIsArchived(ctx context.Context, teamName string, isArchived bool) (*model.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.SetIsArchived")
+	defer span.End()
+
+	teamID, err := s.getTeamID(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, teamName); err != nil {
+		return nil, err
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	if err := s.repos.Team.SetArchived(ctx, teamID, isArchived); err != nil {
+		logFor(ctx, s.logger).Error("Failed to set team archived status", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team archived status updated",
+		zap.String("team_name", teamName),
+		zap.Bool("is_archived", isArchived),
+	)
+
+	return s.recordTeamChange(ctx, AuditActionTeamSetIsArchived, teamName, before)
+}
+
+func (s *teamService) DeleteTeam(ctx context.Context, teamName, targetTeamName string) error {
+	ctx, span := tracing.Start(ctx, "TeamService.DeleteTeam")
+	defer span.End()
+
+	teamID, err := s.getTeamID(ctx, teamName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authz.requireTeamLead(ctx, teamID, teamName); err != nil {
+		return err
+	}
+
+	before := s.snapshotTeam(ctx, teamID)
+
+	var targetTeamID *string
+	if targetTeamName != "" {
+		if targetTeamName == teamName {
+			return errors.ErrBadRequest("target_team_name must differ from team_name")
+		}
+
+		id, err := s.getActiveTeamID(ctx, targetTeamName)
+		if err != nil {
+			return err
+		}
+		if err := s.authz.requireTeamLead(ctx, id, targetTeamName); err != nil {
+			return err
+		}
+		targetTeamID = &id
+	} else {
+		openPRs, err := s.repos.Team.CountOpenPRs(ctx, teamID)
+		if err != nil {
+			logFor(ctx, s.logger).Error("Failed to count open PRs", zap.Error(err))
+			return errors.ErrInternal(err)
+		}
+		if openPRs > 0 {
+			return errors.ErrTeamHasOpenPRs(teamName, openPRs)
+		}
+	}
+
+	userIDs, err := s.repos.Team.Delete(ctx, teamID, targetTeamID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to delete team", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Team deleted",
+		zap.String("team_name", teamName),
+		zap.String("target_team_name", targetTeamName),
+		zap.Int("members", len(userIDs)),
+	)
+
+	s.audit.record(ctx, AuditActionTeamDelete, auditEntityTeam, teamName, before, nil)
+
+	return nil
+}
+
+// recordTeamChange возвращает актуальное состояние команды и пишет изменение в аудит.
+func (s *teamService) recordTeamChange(ctx context.Context, action, teamName string, before *model.Team) (*model.Team, error) {
+	after, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, action, auditEntityTeam, after.TeamName, before, after)
+
+	return after, nil
+}
+
+func (s *teamService) snapshotTeam(ctx context.Context, teamID string) *model.Team {
+	team, err := s.repos.Team.GetByID(ctx, teamID)
+	if err != nil {
+		logFor(ctx, s.logger).Warn("Failed to snapshot team for audit", zap.Error(err))
+		return nil
+	}
+	return team
+}
+
+func (s *teamService) getActiveTeamID(ctx context.Context, teamName string) (string, error) {
+	team, err := s.GetTeam(ctx, teamName)
+	if err != nil {
+		return "", err
+	}
+
+	if team.IsArchived {
+		return "", errors.ErrTeamArchived(teamName)
+	}
+
+	return team.ID, nil
+}
+
+func (s *teamService) getTeamID(ctx context.Context, teamName string) (string, error) {
+	teamID, err := s.repos.Team.GetIDByName(ctx, teamName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+		return "", errors.ErrInternal(err)
+	}
+
+	return teamID, nil
+}
+
+func (s *teamService) getMember(ctx context.Context, userID, teamID, teamName string) (*model.User, error) {
+	user, err := s.repos.User.GetByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	if _, err := s.repos.Membership.Get(ctx, user.ID, teamID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotMember(userID, teamName)
+		}
+		logFor(ctx, s.logger).Error("Failed to get team membership", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return user, nil
+}
+
+func (s *teamService) getUserWithTeams(ctx context.Context, userID string) (*model.User, error) {
+	user, err := s.repos.User.GetByUserID(ctx, userID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	teams, err := s.repos.Membership.GetByUserID(ctx, user.ID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user teams", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	user.Teams = teams
+
+	return user, nil
+}
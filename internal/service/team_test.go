@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"testing"
 
+	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
 	_ "github.com/lib/pq"
@@ -16,7 +18,7 @@ func TestCreateTeam_Success(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewTeamService(repos, logger)
 
@@ -28,7 +30,7 @@ func TestCreateTeam_Success(t *testing.T) {
 		},
 	}
 
-	createdTeam, err := service.CreateTeam(team)
+	createdTeam, err := service.CreateTeam(context.Background(), team)
 	if err != nil {
 		t.Fatalf("Failed to create team: %v", err)
 	}
@@ -49,7 +51,7 @@ func TestCreateTeam_Duplicate(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewTeamService(repos, logger)
 
@@ -60,12 +62,12 @@ func TestCreateTeam_Duplicate(t *testing.T) {
 		},
 	}
 
-	_, err := service.CreateTeam(team)
+	_, err := service.CreateTeam(context.Background(), team)
 	if err != nil {
 		t.Fatalf("Failed to create first team: %v", err)
 	}
 
-	_, err = service.CreateTeam(team)
+	_, err = service.CreateTeam(context.Background(), team)
 	if err == nil {
 		t.Error("Expected error when creating duplicate team")
 	}
@@ -78,7 +80,7 @@ func TestGetTeam_Success(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewTeamService(repos, logger)
 
@@ -90,12 +92,12 @@ func TestGetTeam_Success(t *testing.T) {
 		},
 	}
 
-	_, err := service.CreateTeam(team)
+	_, err := service.CreateTeam(context.Background(), team)
 	if err != nil {
 		t.Fatalf("Failed to create team: %v", err)
 	}
 
-	retrievedTeam, err := service.GetTeam("backend")
+	retrievedTeam, err := service.GetTeam(context.Background(), "backend")
 	if err != nil {
 		t.Fatalf("Failed to get team: %v", err)
 	}
@@ -116,12 +118,359 @@ func TestGetTeam_NotFound(t *testing.T) {
 	}
 	defer db.Close()
 
-	repos := repository.NewRepositories(db)
+	repos := repository.NewRepositories(db, 0)
 	logger, _ := zap.NewDevelopment()
 	service := NewTeamService(repos, logger)
 
-	_, err := service.GetTeam("nonexistent")
+	_, err := service.GetTeam(context.Background(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error when getting nonexistent team")
 	}
-}
\ No newline at end of file
+}
+
+func TestAddMember_Success(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+
+	team, err := service.AddMember(context.Background(), &model.AddTeamMemberRequest{
+		TeamName: "backend",
+		UserID:   "u2",
+		Username: "Bob",
+		IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	if len(team.Members) != 2 {
+		t.Errorf("Expected 2 members, got %d", len(team.Members))
+	}
+
+	_, err = service.AddMember(context.Background(), &model.AddTeamMemberRequest{
+		TeamName: "backend",
+		UserID:   "u2",
+		Username: "Bob",
+		IsActive: true,
+	})
+	if err == nil {
+		t.Error("Expected error when adding existing member")
+	}
+}
+
+func TestRemoveMember_ReassignsOpenReviews(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+	prService := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "David", IsActive: true},
+	})
+
+	pr, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	leaving := pr.AssignedReviewers[0]
+
+	team, err := service.RemoveMember(context.Background(), "backend", leaving)
+	if err != nil {
+		t.Fatalf("Failed to remove member: %v", err)
+	}
+
+	if len(team.Members) != 3 {
+		t.Errorf("Expected 3 members, got %d", len(team.Members))
+	}
+
+	updated, err := repos.PullRequest.GetByPRID(context.Background(), "pr-001")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+
+	for _, r := range updated.AssignedReviewers {
+		if r == leaving {
+			t.Error("Removed member should not stay assigned as reviewer")
+		}
+	}
+
+	if len(updated.AssignedReviewers) != len(pr.AssignedReviewers) {
+		t.Errorf("Expected %d reviewers after reassignment, got %d",
+			len(pr.AssignedReviewers), len(updated.AssignedReviewers))
+	}
+}
+
+func TestMoveMember_Success(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+	createTestTeam(t, repos, "frontend", []model.TeamMember{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	user, err := service.MoveMember(context.Background(), "u1", "", "frontend")
+	if err != nil {
+		t.Fatalf("Failed to move member: %v", err)
+	}
+
+	if user.TeamName != "frontend" {
+		t.Errorf("Expected team 'frontend', got '%s'", user.TeamName)
+	}
+
+	_, err = service.MoveMember(context.Background(), "u1", "", "frontend")
+	if err == nil {
+		t.Error("Expected error when moving user into the same team")
+	}
+}
+
+func TestSetIsArchived_BlocksNewPRs(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+	prService := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	team, err := service.SetIsArchived(context.Background(), "backend", true)
+	if err != nil {
+		t.Fatalf("Failed to archive team: %v", err)
+	}
+
+	if !team.IsArchived {
+		t.Error("Expected team to be archived")
+	}
+
+	_, err = prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err == nil {
+		t.Error("Expected error when creating PR in archived team")
+	}
+}
+
+func TestDeleteTeam_OpenPRs(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+	prService := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+	createTestTeam(t, repos, "platform", []model.TeamMember{
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+
+	_, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	if err := service.DeleteTeam(context.Background(), "backend", ""); err == nil {
+		t.Error("Expected error when deleting team with open PRs")
+	}
+
+	if err := service.DeleteTeam(context.Background(), "backend", "platform"); err != nil {
+		t.Fatalf("Failed to delete team with migration: %v", err)
+	}
+
+	team, err := service.GetTeam(context.Background(), "platform")
+	if err != nil {
+		t.Fatalf("Failed to get target team: %v", err)
+	}
+
+	if len(team.Members) != 3 {
+		t.Errorf("Expected 3 members after migration, got %d", len(team.Members))
+	}
+}
+
+func TestUpdateTeam_RejectsCycle(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+
+	createTestTeam(t, repos, "department", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+	createTestTeam(t, repos, "squad", []model.TeamMember{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	department := "department"
+	if _, err := service.UpdateTeam(context.Background(), &model.UpdateTeamRequest{
+		TeamName:       "squad",
+		ParentTeamName: &department,
+	}); err != nil {
+		t.Fatalf("Failed to set parent team: %v", err)
+	}
+
+	squad := "squad"
+	if _, err := service.UpdateTeam(context.Background(), &model.UpdateTeamRequest{
+		TeamName:       "department",
+		ParentTeamName: &squad,
+	}); err == nil {
+		t.Error("Expected error when creating a cycle in team hierarchy")
+	}
+
+	tree, err := service.GetTeamTree(context.Background(), "department")
+	if err != nil {
+		t.Fatalf("Failed to get team tree: %v", err)
+	}
+
+	if len(tree.SubTeams) != 1 || tree.SubTeams[0].TeamName != "squad" {
+		t.Errorf("Expected sub-team 'squad', got %v", tree.SubTeams)
+	}
+}
+
+func TestUpdateTeam_NoPartialUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+
+	createTestTeam(t, repos, "department", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+	createTestTeam(t, repos, "squad", []model.TeamMember{
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	maxOpenReviews := 3
+	if _, err := service.UpdateTeam(context.Background(), &model.UpdateTeamRequest{
+		TeamName:       "department",
+		NewTeamName:    "squad",
+		MaxOpenReviews: &maxOpenReviews,
+	}); err == nil {
+		t.Error("Expected error when renaming to an existing team")
+	}
+
+	team, err := service.GetTeam(context.Background(), "department")
+	if err != nil {
+		t.Fatalf("Failed to get team: %v", err)
+	}
+
+	if team.MaxOpenReviews != nil {
+		t.Errorf("Expected max_open_reviews to stay unset, got %d", *team.MaxOpenReviews)
+	}
+}
+
+func TestCreateTeam_KeepsExistingUsers(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewTeamService(repos, logger)
+	users := NewUserService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+	})
+
+	if _, err := service.CreateTeam(context.Background(), &model.Team{
+		TeamName: "frontend",
+		Members:  []model.TeamMember{{UserID: "u1", Username: "Mallory", IsActive: false}},
+	}); err != nil {
+		t.Fatalf("Failed to create team: %v", err)
+	}
+
+	user, err := repos.User.GetByUserID(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if !user.IsActive || user.Username != "Alice" {
+		t.Errorf("Expected existing user to stay unchanged, got %+v", user)
+	}
+
+	if err := users.DeleteUser(context.Background(), "u2"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	_, err = service.CreateTeam(context.Background(), &model.Team{
+		TeamName: "platform",
+		Members:  []model.TeamMember{{UserID: "u2", Username: "Bob", IsActive: true}},
+	})
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUserDeleted {
+		t.Errorf("Expected USER_DELETED for deleted user, got %v", err)
+	}
+
+	// Команда создаётся вместе с участниками: после ошибки её нет
+	exists, err := repos.Team.Exists(context.Background(), "platform")
+	if err != nil {
+		t.Fatalf("Failed to check team: %v", err)
+	}
+	if exists {
+		t.Error("Expected team not to be created after failed member insert")
+	}
+}
@@ -1,67 +1,80 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 
 	"go.uber.org/zap"
 	"assign-reviewers-for-pull-requests/internal/errors"
 	"assign-reviewers-for-pull-requests/internal/model"
 	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/tracing"
 )
 
 type UserService interface {
-	SetIsActive(userID string, isActive bool) (*model.User, error)
-	GetReviews(userID string) ([]model.PullRequestShort, error)
+	SetIsActive(ctx context.Context, userID string, isActive bool) (*model.User, error)
+	GetReviews(ctx context.Context, userID string) ([]model.PullRequestShort, error)
+	ExportReviews(ctx context.Context, userID string, fn func(model.PullRequestShort) error) error
+	SetPrimaryTeam(ctx context.Context, userID, teamName string) (*model.User, error)
+	GetUser(ctx context.Context, userID string) (*model.User, error)
+	UpdateUser(ctx context.Context, req *model.UpdateUserRequest) (*model.User, error)
+	DeleteUser(ctx context.Context, userID string) error
+	ListUsers(ctx context.Context, teamName string, isActive *bool, limit, offset int) (*model.UserList, error)
 }
 
 type userService struct {
-	repos  *repository.Repositories
-	logger *zap.Logger
+	repos        *repository.Repositories
+	logger       *zap.Logger
+	audit        *auditRecorder
+	authz        *authorizer
+	pullRequests *pullRequestService
 }
 
 func NewUserService(repos *repository.Repositories, logger *zap.Logger) UserService {
 	return &userService{
-		repos:  repos,
-		logger: logger,
+		repos:        repos,
+		logger:       logger,
+		audit:        newAuditRecorder(repos, logger),
+		authz:        newAuthorizer(repos, logger),
+		pullRequests: newPullRequestService(repos, logger),
 	}
 }
 
-func (s *userService) SetIsActive(userID string, isActive bool) (*model.User, error) {
-	user, err := s.repos.User.GetByUserID(userID)
+func (s *userService) SetIsActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetIsActive")
+	defer span.End()
+
+	user, err := s.GetUser(ctx, userID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.ErrNotFound("user")
-		}
-		s.logger.Error("Failed to get user", zap.Error(err))
-		return nil, errors.ErrInternal(err)
+		return nil, err
 	}
 
-	if err := s.repos.User.SetIsActive(userID, isActive); err != nil {
-		s.logger.Error("Failed to set user active status", zap.Error(err))
+	if err := s.authz.requireLeadOfAll(ctx, user.Teams); err != nil {
+		return nil, err
+	}
+
+	before := *user
+
+	if err := s.repos.User.SetIsActive(ctx, userID, isActive); err != nil {
+		logFor(ctx, s.logger).Error("Failed to set user active status", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	if !isActive {
-		prs, err := s.repos.PullRequest.GetPRsByReviewerUserID(userID)
-		if err == nil {
-			for _, pr := range prs {
-				if pr.Status == "OPEN" {
-					prObj, err := s.repos.PullRequest.GetByPRID(pr.PullRequestID)
-					if err == nil {
-						_ = s.repos.PullRequest.RemoveReviewer(prObj.ID, user.ID)
-						s.logger.Info("Removed inactive reviewer from PR",
-							zap.String("user_id", userID),
-							zap.String("pr_id", pr.PullRequestID),
-						)
-					}
-				}
-			}
+		if err := s.removeFromOpenReviews(ctx, user); err != nil {
+			logFor(ctx, s.logger).Error("Failed to remove inactive reviewer", zap.Error(err))
+			return nil, errors.ErrInternal(err)
 		}
 	}
 
 	user.IsActive = isActive
 
-	s.logger.Info("User active status updated",
+	s.audit.record(ctx, AuditActionUserSetIsActive, auditEntityUser, userID, before, user)
+	if before.IsActive && !isActive {
+		s.pullRequests.events.publish(ctx, model.EventUserDeactivated, model.UserEventData{User: user})
+	}
+
+	logFor(ctx, s.logger).Info("User active status updated",
 		zap.String("user_id", userID),
 		zap.Bool("is_active", isActive),
 	)
@@ -69,12 +82,246 @@ func (s *userService) SetIsActive(userID string, isActive bool) (*model.User, er
 	return user, nil
 }
 
-func (s *userService) GetReviews(userID string) ([]model.PullRequestShort, error) {
-	prs, err := s.repos.PullRequest.GetPRsByReviewerUserID(userID)
+// removeFromOpenReviews снимает пользователя с открытых PR; PR, изменённый
+// за это время, пропускается.
+func (s *userService) removeFromOpenReviews(ctx context.Context, user *model.User) error {
+	prs, err := s.repos.PullRequest.GetPRsByReviewerUserID(ctx, user.UserID)
 	if err != nil {
-		s.logger.Error("Failed to get user reviews", zap.Error(err))
+		return err
+	}
+
+	for _, short := range prs {
+		if short.Status != "OPEN" {
+			continue
+		}
+
+		pr, err := s.repos.PullRequest.GetByPRID(ctx, short.PullRequestID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.repos.PullRequest.RemoveReviewer(ctx, pr.ID, user.ID, model.ReviewerActionDeactivation, pr.Version); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return err
+		}
+
+		logFor(ctx, s.logger).Info("Removed inactive reviewer from PR",
+			zap.String("user_id", user.UserID),
+			zap.String("pr_id", short.PullRequestID),
+		)
+	}
+
+	return nil
+}
+
+func (s *userService) GetReviews(ctx context.Context, userID string) ([]model.PullRequestShort, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReviews")
+	defer span.End()
+
+	prs, err := s.repos.PullRequest.GetPRsByReviewerUserID(ctx, userID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user reviews", zap.Error(err))
 		return nil, errors.ErrInternal(err)
 	}
 
 	return prs, nil
-}
\ No newline at end of file
+}
+
+func (s *userService) ExportReviews(ctx context.Context, userID string, fn func(model.PullRequestShort) error) error {
+	ctx, span := tracing.Start(ctx, "UserService.ExportReviews")
+	defer span.End()
+
+	if err := s.repos.PullRequest.StreamPRsByReviewerUserID(ctx, userID, fn); err != nil {
+		logFor(ctx, s.logger).Error("Failed to export user reviews", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	return nil
+}
+
+func (s *userService) SetPrimaryTeam(ctx context.Context, userID, teamName string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetPrimaryTeam")
+	defer span.End()
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireLeadOfAll(ctx, user.Teams); err != nil {
+		return nil, err
+	}
+
+	teamID, err := s.repos.Team.GetIDByName(ctx, teamName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("team")
+		}
+		logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	before := *user
+
+	if err := s.repos.Membership.SetPrimary(ctx, user.ID, teamID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotMember(userID, teamName)
+		}
+		logFor(ctx, s.logger).Error("Failed to set primary team", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	teams, err := s.repos.Membership.GetByUserID(ctx, user.ID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user teams", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	user.TeamID = teamID
+	user.TeamName = teamName
+	user.Teams = teams
+
+	s.audit.record(ctx, AuditActionUserSetPrimaryTeam, auditEntityUser, userID, before, user)
+
+	logFor(ctx, s.logger).Info("User primary team updated",
+		zap.String("user_id", userID),
+		zap.String("team_name", teamName),
+	)
+
+	return user, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetUser")
+	defer span.End()
+
+	user, err := s.repos.User.GetByUserID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to get user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	teams, err := s.repos.Membership.GetByUserID(ctx, user.ID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to get user teams", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	user.Teams = teams
+
+	return user, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, req *model.UpdateUserRequest) (*model.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
+	if req.Username != nil && *req.Username == "" {
+		return nil, errors.ErrBadRequest("username must not be empty")
+	}
+
+	before, err := s.GetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authz.requireLeadOfAll(ctx, before.Teams); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.User.Update(ctx, req.UserID, req.Username, req.Attributes); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to update user", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("User updated", zap.String("user_id", req.UserID))
+
+	after, err := s.GetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.record(ctx, AuditActionUserUpdate, auditEntityUser, req.UserID, before, after)
+
+	return after, nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "UserService.DeleteUser")
+	defer span.End()
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.authz.requireLeadOfAll(ctx, user.Teams); err != nil {
+		return err
+	}
+
+	reassigned, err := s.pullRequests.reassignOpenReviews(ctx, user, "", model.ReviewerActionUserDeleted)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to reassign open reviews", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	if _, err := s.repos.User.SoftDelete(ctx, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound("user")
+		}
+		logFor(ctx, s.logger).Error("Failed to delete user", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+
+	s.audit.record(ctx, AuditActionUserDelete, auditEntityUser, userID, user, nil)
+
+	logFor(ctx, s.logger).Info("User deleted",
+		zap.String("user_id", userID),
+		zap.Int("reassigned_reviews", reassigned),
+	)
+
+	return nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, teamName string, isActive *bool, limit, offset int) (*model.UserList, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	filter := model.UserFilter{
+		IsActive: isActive,
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	if teamName != "" {
+		teamID, err := s.repos.Team.GetIDByName(ctx, teamName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.ErrNotFound("team")
+			}
+			logFor(ctx, s.logger).Error("Failed to get team", zap.Error(err))
+			return nil, errors.ErrInternal(err)
+		}
+		filter.TeamID = teamID
+	}
+
+	users, total, err := s.repos.User.List(ctx, filter)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list users", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.UserList{
+		Users:  users,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+func TestUpdateUser_Success(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewUserService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	})
+
+	username := "Alice Smith"
+	user, err := service.UpdateUser(context.Background(), &model.UpdateUserRequest{
+		UserID:     "u1",
+		Username:   &username,
+		Attributes: model.Attributes{"timezone": "UTC"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+
+	if user.Username != username {
+		t.Errorf("Expected username '%s', got '%s'", username, user.Username)
+	}
+
+	if user.Attributes["timezone"] != "UTC" {
+		t.Errorf("Expected timezone attribute 'UTC', got %v", user.Attributes["timezone"])
+	}
+}
+
+func TestDeleteUser_ReassignsOpenReviews(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	service := NewUserService(repos, logger)
+	prService := NewPullRequestService(repos, logger)
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "David", IsActive: true},
+	})
+
+	pr, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID:   "pr-001",
+		PullRequestName: "Test PR",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	deleted := pr.AssignedReviewers[0]
+	if err := service.DeleteUser(context.Background(), deleted); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	if _, err := service.GetUser(context.Background(), deleted); err == nil {
+		t.Error("Expected error when getting deleted user")
+	}
+
+	updated, err := repos.PullRequest.GetByPRID(context.Background(), "pr-001")
+	if err != nil {
+		t.Fatalf("Failed to get PR: %v", err)
+	}
+
+	for _, r := range updated.AssignedReviewers {
+		if r == deleted {
+			t.Error("Deleted user should not stay assigned as reviewer")
+		}
+	}
+
+	list, err := service.ListUsers(context.Background(), "backend", nil, 50, 0)
+	if err != nil {
+		t.Fatalf("Failed to list users: %v", err)
+	}
+
+	if list.Total != 3 {
+		t.Errorf("Expected 3 users after deletion, got %d", list.Total)
+	}
+}
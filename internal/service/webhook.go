@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"assign-reviewers-for-pull-requests/internal/errors"
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/notify"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	"assign-reviewers-for-pull-requests/internal/requestctx"
+	"assign-reviewers-for-pull-requests/internal/tracing"
+)
+
+// WebhookOptions — доставка исходящих событий. Ответа подписчика ждём
+// Timeout; после неудачи повторяем через RetryBase, 2×RetryBase и так
+// далее, но не реже RetryMax, а после MaxAttempts попыток доставка
+// становится DEAD.
+type WebhookOptions struct {
+	Timeout     time.Duration
+	BatchSize   int
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+}
+
+// deliveryLeaseMargin — запас сверх Timeout, на который воркер забирает
+// доставку; за это время он должен записать результат попытки.
+const deliveryLeaseMargin = time.Minute
+
+// maxDeliveryErrorLength — сколько символов ошибки сохраняется в журнале доставок.
+const maxDeliveryErrorLength = 1000
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, req *model.CreateWebhookSubscriptionRequest) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionID string) error
+	ListDeliveries(ctx context.Context, filter model.EventDeliveryFilter) (*model.EventDeliveryList, error)
+	Redeliver(ctx context.Context, deliveryID string) (*model.EventDelivery, error)
+	DeliverPending(ctx context.Context) (*model.EventDeliveryResult, error)
+}
+
+type webhookService struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+	sender notify.EventSender
+	opts   WebhookOptions
+}
+
+func NewWebhookService(repos *repository.Repositories, logger *zap.Logger, opts WebhookOptions) WebhookService {
+	return &webhookService{
+		repos:  repos,
+		logger: logger,
+		sender: notify.NewEventSender(opts.Timeout),
+		opts:   opts,
+	}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, req *model.CreateWebhookSubscriptionRequest) (*model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.CreateSubscription")
+	defer span.End()
+
+	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
+		return nil, errors.ErrBadRequest("url must use http or https")
+	}
+
+	sub := &model.WebhookSubscription{
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: uniqueStrings(req.Events),
+	}
+	if err := s.repos.Webhook.CreateSubscription(ctx, sub); err != nil {
+		logFor(ctx, s.logger).Error("Failed to create webhook subscription", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	logFor(ctx, s.logger).Info("Webhook subscription created",
+		zap.String("subscription_id", sub.ID),
+		zap.Strings("events", sub.Events),
+	)
+
+	return sub, nil
+}
+
+func (s *webhookService) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListSubscriptions")
+	defer span.End()
+
+	subs, err := s.repos.Webhook.ListSubscriptions(ctx)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list webhook subscriptions", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	return subs, nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, subscriptionID string) error {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeleteSubscription")
+	defer span.End()
+
+	deleted, err := s.repos.Webhook.DeleteSubscription(ctx, subscriptionID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to delete webhook subscription", zap.Error(err))
+		return errors.ErrInternal(err)
+	}
+	if !deleted {
+		return errors.ErrNotFound("webhook subscription")
+	}
+
+	logFor(ctx, s.logger).Info("Webhook subscription deleted", zap.String("subscription_id", subscriptionID))
+
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, filter model.EventDeliveryFilter) (*model.EventDeliveryList, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	deliveries, total, err := s.repos.Webhook.ListDeliveries(ctx, filter)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to list webhook deliveries", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return &model.EventDeliveryList{
+		Deliveries: deliveries,
+		Total:      total,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	}, nil
+}
+
+// Redeliver ставит доставку, в том числе DEAD, в очередь заново.
+func (s *webhookService) Redeliver(ctx context.Context, deliveryID string) (*model.EventDelivery, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Redeliver")
+	defer span.End()
+
+	requeued, err := s.repos.Webhook.Requeue(ctx, deliveryID)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to requeue webhook delivery", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+	if !requeued {
+		return nil, errors.ErrNotFound("webhook delivery")
+	}
+
+	delivery, err := s.repos.Webhook.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound("webhook delivery")
+		}
+		logFor(ctx, s.logger).Error("Failed to get webhook delivery", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	return delivery, nil
+}
+
+// DeliverPending отправляет подошедшие доставки параллельно и записывает
+// результат каждой попытки.
+func (s *webhookService) DeliverPending(ctx context.Context) (*model.EventDeliveryResult, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.DeliverPending")
+	defer span.End()
+
+	deliveries, err := s.repos.Webhook.ClaimDue(ctx, s.opts.BatchSize, s.opts.Timeout+deliveryLeaseMargin)
+	if err != nil {
+		logFor(ctx, s.logger).Error("Failed to claim webhook deliveries", zap.Error(err))
+		return nil, errors.ErrInternal(err)
+	}
+
+	result := &model.EventDeliveryResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery model.ClaimedEventDelivery) {
+			defer wg.Done()
+
+			status := s.deliver(ctx, delivery)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch status {
+			case model.EventDeliveryDelivered:
+				result.Delivered++
+			case model.EventDeliveryDead:
+				result.Dead++
+			default:
+				result.Retried++
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return result, nil
+}
+
+// deliver выполняет одну попытку и возвращает новое состояние доставки.
+func (s *webhookService) deliver(ctx context.Context, delivery model.ClaimedEventDelivery) string {
+	attempt := delivery.Attempts + 1
+	status := model.EventDeliveryDelivered
+	var retryAfter time.Duration
+	var lastError string
+
+	body, err := json.Marshal(delivery.WebhookEvent)
+	responseStatus := 0
+	if err == nil {
+		responseStatus, err = s.sender.Send(ctx, delivery.URL, delivery.Secret, delivery.Type, delivery.DeliveryID, body)
+	}
+	if err != nil {
+		lastError = truncateError(err.Error())
+		if attempt >= s.opts.MaxAttempts {
+			status = model.EventDeliveryDead
+		} else {
+			status = model.EventDeliveryPending
+			retryAfter = retryDelay(s.opts.RetryBase, s.opts.RetryMax, attempt)
+		}
+	}
+
+	if err := s.repos.Webhook.RecordAttempt(ctx, delivery.DeliveryID, status, responseStatus, lastError, retryAfter); err != nil {
+		logFor(ctx, s.logger).Error("Failed to record webhook delivery attempt",
+			zap.String("delivery_id", delivery.DeliveryID),
+			zap.Error(err),
+		)
+	}
+	webhookDeliveryAttempts.Inc(strings.ToLower(status))
+
+	fields := []zap.Field{
+		zap.String("delivery_id", delivery.DeliveryID),
+		zap.String("event_type", delivery.Type),
+		zap.Int("attempt", attempt),
+		zap.String("status", status),
+	}
+	switch status {
+	case model.EventDeliveryDelivered:
+		logFor(ctx, s.logger).Debug("Webhook event delivered", fields...)
+	case model.EventDeliveryDead:
+		logFor(ctx, s.logger).Warn("Webhook delivery moved to dead letters", append(fields, zap.String("error", lastError))...)
+	default:
+		logFor(ctx, s.logger).Info("Webhook delivery failed, will retry",
+			append(fields, zap.Duration("retry_after", retryAfter), zap.String("error", lastError))...)
+	}
+
+	return status
+}
+
+// retryDelay — пауза после attempt-й неудачной попытки: base·2^(attempt-1),
+// но не больше max.
+func retryDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+func truncateError(message string) string {
+	if len(message) <= maxDeliveryErrorLength {
+		return message
+	}
+	return message[:maxDeliveryErrorLength]
+}
+
+func uniqueStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// eventPublisher ставит события в очередь доставки подписчикам. Как и
+// запись аудита, ошибка не откатывает выполненную операцию, а только логируется.
+type eventPublisher struct {
+	repos  *repository.Repositories
+	logger *zap.Logger
+}
+
+func newEventPublisher(repos *repository.Repositories, logger *zap.Logger) *eventPublisher {
+	return &eventPublisher{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+func (p *eventPublisher) publish(ctx context.Context, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logFor(ctx, p.logger).Warn("Failed to marshal webhook event", zap.String("event_type", eventType), zap.Error(err))
+		return
+	}
+
+	if _, err := p.repos.Webhook.Publish(ctx, eventType, requestctx.Actor(ctx), payload); err != nil {
+		logFor(ctx, p.logger).Error("Failed to publish webhook event",
+			zap.String("event_type", eventType),
+			zap.Error(err),
+		)
+	}
+}
+
+func (p *eventPublisher) reviewerAssigned(ctx context.Context, pr *model.PullRequest, reviewerID, reason string) {
+	p.publish(ctx, model.EventReviewerAssigned, model.ReviewerEventData{
+		PullRequestID:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorID:        pr.AuthorID,
+		ReviewerID:      reviewerID,
+		Reason:          reason,
+	})
+}
+
+func (p *eventPublisher) reviewerReassigned(ctx context.Context, pr *model.PullRequest, oldReviewerID, newReviewerID, reason string) {
+	p.publish(ctx, model.EventReviewerReassigned, model.ReviewerEventData{
+		PullRequestID:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorID:        pr.AuthorID,
+		ReviewerID:      newReviewerID,
+		OldReviewerID:   oldReviewerID,
+		Reason:          reason,
+	})
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"assign-reviewers-for-pull-requests/internal/model"
+	"assign-reviewers-for-pull-requests/internal/notify"
+	"assign-reviewers-for-pull-requests/internal/repository"
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const testSubscriptionSecret = "subscription-secret-0123456789"
+
+// subscriber — подписчик, который проверяет подпись и отвечает status.
+type subscriber struct {
+	t      *testing.T
+	mu     sync.Mutex
+	status int
+	events []model.WebhookEvent
+}
+
+func (s *subscriber) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get(notify.HeaderSignature) != notify.Sign(testSubscriptionSecret, body) {
+		s.t.Errorf("Invalid signature for delivery %s", r.Header.Get(notify.HeaderDelivery))
+	}
+
+	var event model.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		s.t.Errorf("Invalid event body: %v", err)
+	}
+	if r.Header.Get(notify.HeaderEvent) != event.Type {
+		s.t.Errorf("Expected %s header %q, got %q", notify.HeaderEvent, event.Type, r.Header.Get(notify.HeaderEvent))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	w.WriteHeader(s.status)
+}
+
+func (s *subscriber) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func newTestWebhookService(repos *repository.Repositories) WebhookService {
+	logger, _ := zap.NewDevelopment()
+	return NewWebhookService(repos, logger, WebhookOptions{
+		Timeout:     5 * time.Second,
+		BatchSize:   10,
+		MaxAttempts: 2,
+		RetryBase:   time.Minute,
+		RetryMax:    time.Hour,
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(30*time.Second, 10*time.Minute, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(attempt=%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDelivery_RetryDeadLetterAndRedeliver(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	webhooks := newTestWebhookService(repos)
+	prService := NewPullRequestService(repos, logger)
+
+	sub := &subscriber{t: t, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	subscription, err := webhooks.CreateSubscription(context.Background(), &model.CreateWebhookSubscriptionRequest{
+		URL:    server.URL,
+		Secret: testSubscriptionSecret,
+		Events: []string{model.EventReviewerAssigned},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+	})
+	if _, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID: "pr-001", PullRequestName: "Test PR", AuthorID: "u1",
+	}); err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+
+	// pr.created не входит в фильтр подписки — только два назначения
+	result, err := webhooks.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if result.Retried != 2 {
+		t.Fatalf("Expected 2 retried deliveries, got %+v", result)
+	}
+
+	list, err := webhooks.ListDeliveries(context.Background(), model.EventDeliveryFilter{SubscriptionID: subscription.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list deliveries: %v", err)
+	}
+	for _, delivery := range list.Deliveries {
+		if delivery.Status != model.EventDeliveryPending || delivery.Attempts != 1 || delivery.NextAttemptAt == nil {
+			t.Errorf("Expected pending delivery after first attempt, got %+v", delivery)
+		}
+		if delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusServiceUnavailable {
+			t.Errorf("Expected response status 503, got %v", delivery.ResponseStatus)
+		}
+	}
+
+	// До следующей попытки ещё минута
+	result, _ = webhooks.DeliverPending(context.Background())
+	if result.Retried+result.Dead+result.Delivered != 0 {
+		t.Errorf("Expected no due deliveries, got %+v", result)
+	}
+
+	if _, err := db.Exec("UPDATE webhook_deliveries SET next_attempt_at = NOW()"); err != nil {
+		t.Fatalf("Failed to make deliveries due: %v", err)
+	}
+	result, _ = webhooks.DeliverPending(context.Background())
+	if result.Dead != 2 {
+		t.Fatalf("Expected 2 dead deliveries, got %+v", result)
+	}
+
+	sub.setStatus(http.StatusNoContent)
+	delivery, err := webhooks.Redeliver(context.Background(), list.Deliveries[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to redeliver: %v", err)
+	}
+	if delivery.Status != model.EventDeliveryPending || delivery.Attempts != 0 {
+		t.Errorf("Expected requeued delivery, got %+v", delivery)
+	}
+
+	result, _ = webhooks.DeliverPending(context.Background())
+	if result.Delivered != 1 {
+		t.Errorf("Expected 1 delivered, got %+v", result)
+	}
+
+	dead, _ := webhooks.ListDeliveries(context.Background(), model.EventDeliveryFilter{Status: model.EventDeliveryDead, Limit: 10})
+	if dead.Total != 1 {
+		t.Errorf("Expected 1 dead delivery left, got %d", dead.Total)
+	}
+
+	for _, event := range sub.events {
+		if event.Type != model.EventReviewerAssigned {
+			t.Errorf("Expected only reviewer.assigned, got %s", event.Type)
+		}
+	}
+}
+
+func TestWebhookEvents_Published(t *testing.T) {
+	db := setupTestDB(t)
+	if db == nil {
+		return
+	}
+	defer db.Close()
+
+	repos := repository.NewRepositories(db, 0)
+	logger, _ := zap.NewDevelopment()
+	webhooks := newTestWebhookService(repos)
+	prService := NewPullRequestService(repos, logger)
+	userService := NewUserService(repos, logger)
+
+	sub := &subscriber{t: t, status: http.StatusOK}
+	server := httptest.NewServer(sub)
+	defer server.Close()
+
+	if _, err := webhooks.CreateSubscription(context.Background(), &model.CreateWebhookSubscriptionRequest{
+		URL: server.URL, Secret: testSubscriptionSecret,
+	}); err != nil {
+		t.Fatalf("Failed to create subscription: %v", err)
+	}
+
+	createTestTeam(t, repos, "backend", []model.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+		{UserID: "u2", Username: "Bob", IsActive: true},
+		{UserID: "u3", Username: "Charlie", IsActive: true},
+		{UserID: "u4", Username: "Dave", IsActive: true},
+	})
+
+	pr, err := prService.CreatePR(context.Background(), &model.CreatePRRequest{
+		PullRequestID: "pr-001", PullRequestName: "Test PR", AuthorID: "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create PR: %v", err)
+	}
+	if _, _, err := prService.ReassignReviewer(context.Background(), "pr-001", pr.AssignedReviewers[0], 0); err != nil {
+		t.Fatalf("Failed to reassign reviewer: %v", err)
+	}
+	if _, err := prService.MergePR(context.Background(), "pr-001", 0); err != nil {
+		t.Fatalf("Failed to merge PR: %v", err)
+	}
+	if _, err := userService.SetIsActive(context.Background(), "u4", false); err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+	// Повторная деактивация события не порождает
+	if _, err := userService.SetIsActive(context.Background(), "u4", false); err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+
+	if _, err := webhooks.DeliverPending(context.Background()); err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+
+	counts := map[string]int{}
+	for _, event := range sub.events {
+		counts[event.Type]++
+	}
+	expected := map[string]int{
+		model.EventPRCreated:          1,
+		model.EventReviewerAssigned:   2,
+		model.EventReviewerReassigned: 1,
+		model.EventPRMerged:           1,
+		model.EventUserDeactivated:    1,
+	}
+	for eventType, count := range expected {
+		if counts[eventType] != count {
+			t.Errorf("Expected %d %s events, got %d", count, eventType, counts[eventType])
+		}
+	}
+}
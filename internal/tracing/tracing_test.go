@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTraceparent_RoundTrip(t *testing.T) {
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, ok := ParseTraceparent(header)
+	if !ok {
+		t.Fatal("Expected valid traceparent")
+	}
+
+	if got := FormatTraceparent(sc); got != header {
+		t.Errorf("Expected %s, got %s", header, got)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceparent(invalid); ok {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestStart_DisabledReturnsNilSpan(t *testing.T) {
+	SetTracer(nil)
+
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil {
+		t.Fatal("Expected nil span when tracing is disabled")
+	}
+
+	span.SetAttributes(String("key", "value"))
+	span.RecordError(errors.New("ignored"))
+	span.End()
+
+	if SpanContextFromContext(ctx).IsValid() {
+		t.Error("Expected no span context when tracing is disabled")
+	}
+}
+
+func TestTracer_ExportsParentChildSpans(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewTracer(NewWriterExporter("test-service", &buf), zap.NewNop())
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ContextWithSpanContext(context.Background(), parent)
+
+	ctx, server := StartWithKind(ctx, KindServer, "POST /pullRequest/create")
+	_, query := StartWithKind(ctx, KindClient, "db.select", String("db.statement", "SELECT 1"))
+	query.RecordError(errors.New("timeout"))
+	query.End()
+	server.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Failed to shutdown tracer: %v", err)
+	}
+
+	var request otlpRequest
+	if err := json.Unmarshal(buf.Bytes(), &request); err != nil {
+		t.Fatalf("Failed to decode export: %v (%s)", err, buf.String())
+	}
+
+	spans := request.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+
+	dbSpan, serverSpan := spans[0], spans[1]
+	if serverSpan.TraceID != parent.TraceID.String() || serverSpan.ParentSpanID != parent.SpanID.String() {
+		t.Errorf("Server span is not linked to remote parent: %+v", serverSpan)
+	}
+	if dbSpan.TraceID != serverSpan.TraceID || dbSpan.ParentSpanID != serverSpan.SpanID {
+		t.Errorf("DB span is not a child of server span: %+v", dbSpan)
+	}
+	if dbSpan.Status == nil || dbSpan.Status.Message != "timeout" {
+		t.Errorf("Expected error status on DB span, got %+v", dbSpan.Status)
+	}
+	if *request.ResourceSpans[0].Resource.Attributes[0].Value.StringValue != "test-service" {
+		t.Errorf("Expected service.name resource attribute")
+	}
+}
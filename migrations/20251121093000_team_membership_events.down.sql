@@ -0,0 +1 @@
+DROP TABLE IF EXISTS team_membership_events CASCADE;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS team_membership_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('ADD', 'REMOVE', 'MOVE')),
+    from_team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    to_team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_membership_events_user_id ON team_membership_events(user_id);
+CREATE INDEX idx_membership_events_created_at ON team_membership_events(created_at);
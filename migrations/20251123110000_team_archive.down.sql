@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_teams_is_archived;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_id_fkey;
+ALTER TABLE users
+    ADD CONSTRAINT users_team_id_fkey FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE SET NULL;
+
+ALTER TABLE teams
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS is_archived;
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS is_archived BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+-- Команду нельзя удалить, пока в ней есть участники: удаление идёт через сервис,
+-- который переносит или открепляет участников явно.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_id_fkey;
+ALTER TABLE users
+    ADD CONSTRAINT users_team_id_fkey FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE RESTRICT;
+
+CREATE INDEX idx_teams_is_archived ON teams(is_archived);
@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS team_id UUID REFERENCES teams(id) ON DELETE RESTRICT;
+
+UPDATE users u
+SET team_id = m.team_id
+FROM team_memberships m
+WHERE m.user_id = u.id AND m.is_primary;
+
+CREATE INDEX IF NOT EXISTS idx_users_team_id ON users(team_id);
+
+DROP INDEX IF EXISTS idx_pr_team_id;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS team_id;
+
+DROP TABLE IF EXISTS team_memberships CASCADE;
\ No newline at end of file
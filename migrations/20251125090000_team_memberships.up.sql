@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS team_memberships (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    team_id UUID NOT NULL REFERENCES teams(id) ON DELETE RESTRICT,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, team_id)
+);
+
+CREATE INDEX idx_memberships_team_id ON team_memberships(team_id);
+CREATE UNIQUE INDEX idx_memberships_primary ON team_memberships(user_id) WHERE is_primary;
+
+INSERT INTO team_memberships (user_id, team_id, is_primary, is_active)
+SELECT id, team_id, TRUE, TRUE
+FROM users
+WHERE team_id IS NOT NULL
+ON CONFLICT (user_id, team_id) DO NOTHING;
+
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS team_id UUID REFERENCES teams(id) ON DELETE SET NULL;
+
+UPDATE pull_requests pr
+SET team_id = u.team_id
+FROM users u
+WHERE pr.author_id = u.id AND pr.team_id IS NULL;
+
+CREATE INDEX idx_pr_team_id ON pull_requests(team_id);
+
+DROP INDEX IF EXISTS idx_users_team_id;
+ALTER TABLE users DROP COLUMN IF EXISTS team_id;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_teams_parent_team_id;
+
+ALTER TABLE teams
+    DROP CONSTRAINT IF EXISTS chk_teams_max_open_reviews,
+    DROP CONSTRAINT IF EXISTS chk_teams_parent_not_self,
+    DROP COLUMN IF EXISTS max_open_reviews,
+    DROP COLUMN IF EXISTS parent_team_id;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS parent_team_id UUID REFERENCES teams(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS max_open_reviews INTEGER;
+
+ALTER TABLE teams
+    ADD CONSTRAINT chk_teams_parent_not_self CHECK (parent_team_id IS NULL OR parent_team_id <> id),
+    ADD CONSTRAINT chk_teams_max_open_reviews CHECK (max_open_reviews IS NULL OR max_open_reviews > 0);
+
+CREATE INDEX idx_teams_parent_team_id ON teams(parent_team_id);
@@ -0,0 +1,20 @@
+CREATE TABLE pr_reviewer_history (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    assigned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    assigned_action VARCHAR(20) NOT NULL
+        CHECK (assigned_action IN ('CREATE', 'REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'MANUAL')),
+    removed_at TIMESTAMP,
+    removed_action VARCHAR(20)
+        CHECK (removed_action IN ('REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'MANUAL')),
+    CONSTRAINT chk_removed CHECK ((removed_at IS NULL) = (removed_action IS NULL))
+);
+
+CREATE INDEX idx_reviewer_history_pr_id ON pr_reviewer_history(pull_request_id);
+CREATE INDEX idx_reviewer_history_user_id ON pr_reviewer_history(user_id);
+
+INSERT INTO pr_reviewer_history (pull_request_id, user_id, assigned_at, assigned_action)
+SELECT pull_request_id, user_id, assigned_at, 'CREATE'
+FROM pr_reviewers
+ORDER BY assigned_at;
@@ -0,0 +1,23 @@
+ALTER TABLE pr_reviewer_history
+    DROP CONSTRAINT IF EXISTS pr_reviewer_history_assigned_action_check,
+    DROP CONSTRAINT IF EXISTS pr_reviewer_history_removed_action_check;
+
+UPDATE pr_reviewer_history SET removed_action = 'REASSIGN' WHERE removed_action = 'STALE';
+UPDATE pr_reviewer_history SET assigned_action = 'REASSIGN' WHERE assigned_action = 'STALE';
+
+ALTER TABLE pr_reviewer_history
+    ADD CONSTRAINT pr_reviewer_history_assigned_action_check
+        CHECK (assigned_action IN ('CREATE', 'REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'MANUAL')),
+    ADD CONSTRAINT pr_reviewer_history_removed_action_check
+        CHECK (removed_action IN ('REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'MANUAL'));
+
+DROP INDEX IF EXISTS idx_pr_reviewers_assigned_at;
+
+ALTER TABLE pr_reviewers
+    DROP COLUMN IF EXISTS escalated_at;
+
+ALTER TABLE teams
+    DROP CONSTRAINT IF EXISTS chk_teams_stale_policy,
+    DROP CONSTRAINT IF EXISTS chk_teams_review_sla_hours,
+    DROP COLUMN IF EXISTS stale_policy,
+    DROP COLUMN IF EXISTS review_sla_hours;
\ No newline at end of file
@@ -0,0 +1,22 @@
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS review_sla_hours INTEGER,
+    ADD COLUMN IF NOT EXISTS stale_policy VARCHAR(20) NOT NULL DEFAULT 'NUDGE';
+
+ALTER TABLE teams
+    ADD CONSTRAINT chk_teams_review_sla_hours CHECK (review_sla_hours IS NULL OR review_sla_hours > 0),
+    ADD CONSTRAINT chk_teams_stale_policy CHECK (stale_policy IN ('NUDGE', 'ADD_REVIEWER', 'REASSIGN'));
+
+ALTER TABLE pr_reviewers
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP;
+
+CREATE INDEX idx_pr_reviewers_assigned_at ON pr_reviewers(assigned_at);
+
+ALTER TABLE pr_reviewer_history
+    DROP CONSTRAINT IF EXISTS pr_reviewer_history_assigned_action_check,
+    DROP CONSTRAINT IF EXISTS pr_reviewer_history_removed_action_check;
+
+ALTER TABLE pr_reviewer_history
+    ADD CONSTRAINT pr_reviewer_history_assigned_action_check
+        CHECK (assigned_action IN ('CREATE', 'REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'STALE', 'MANUAL')),
+    ADD CONSTRAINT pr_reviewer_history_removed_action_check
+        CHECK (removed_action IN ('REASSIGN', 'DEACTIVATION', 'TEAM_CHANGE', 'USER_DELETED', 'STALE', 'MANUAL'));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS review_decisions;
\ No newline at end of file
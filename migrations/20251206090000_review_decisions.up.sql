@@ -0,0 +1,9 @@
+CREATE TABLE review_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id UUID NOT NULL REFERENCES pull_requests(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    decision VARCHAR(20) NOT NULL CHECK (decision IN ('APPROVED', 'CHANGES_REQUESTED')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_review_decisions_pr_user ON review_decisions(pull_request_id, user_id, created_at);
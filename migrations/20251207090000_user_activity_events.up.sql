@@ -0,0 +1,12 @@
+CREATE TABLE user_activity_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_active BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_user_activity_events_user_id ON user_activity_events(user_id, created_at);
+
+INSERT INTO user_activity_events (user_id, is_active, created_at)
+SELECT id, is_active AND deleted_at IS NULL, created_at
+FROM users;
@@ -0,0 +1,4 @@
+-- Версия PR растёт при каждом изменении статуса или состава ревьюверов
+-- и используется для оптимистичной блокировки.
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS user_roles;
\ No newline at end of file
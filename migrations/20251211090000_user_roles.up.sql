@@ -0,0 +1,16 @@
+CREATE TABLE user_roles (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL,
+    team_id UUID REFERENCES teams(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT user_roles_scope CHECK (
+        (role IN ('ORG_ADMIN', 'BOT') AND team_id IS NULL) OR
+        (role IN ('TEAM_LEAD', 'MEMBER') AND team_id IS NOT NULL)
+    )
+);
+
+CREATE UNIQUE INDEX idx_user_roles_org ON user_roles(user_id, role) WHERE team_id IS NULL;
+CREATE UNIQUE INDEX idx_user_roles_team ON user_roles(user_id, role, team_id) WHERE team_id IS NOT NULL;
+
+ALTER TABLE api_tokens ADD COLUMN user_id UUID REFERENCES users(id) ON DELETE CASCADE;
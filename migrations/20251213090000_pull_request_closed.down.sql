@@ -0,0 +1,12 @@
+-- Закрытые без merge PR возвращаются в OPEN: иначе их нельзя представить
+UPDATE pull_requests SET status = 'OPEN' WHERE status = 'CLOSED';
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS chk_merged_at;
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('OPEN', 'MERGED')),
+    ADD CONSTRAINT chk_merged_at CHECK (
+        (status = 'MERGED' AND merged_at IS NOT NULL) OR
+        (status = 'OPEN' AND merged_at IS NULL)
+    );
\ No newline at end of file
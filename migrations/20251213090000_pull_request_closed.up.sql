@@ -0,0 +1,6 @@
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS chk_merged_at;
+
+ALTER TABLE pull_requests
+    ADD CONSTRAINT pull_requests_status_check CHECK (status IN ('OPEN', 'MERGED', 'CLOSED')),
+    ADD CONSTRAINT chk_merged_at CHECK ((status = 'MERGED') = (merged_at IS NOT NULL));
@@ -0,0 +1,11 @@
+CREATE TABLE forge_user_mappings (
+    forge VARCHAR(32) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    external_id BIGINT,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (forge, login)
+);
+
+CREATE INDEX idx_forge_user_mappings_user_id ON forge_user_mappings(user_id);
+CREATE UNIQUE INDEX idx_forge_user_mappings_external_id ON forge_user_mappings(forge, external_id) WHERE external_id IS NOT NULL;